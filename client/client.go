@@ -6,10 +6,12 @@ import (
 	"strconv"
 	"sync"
 
+	"github.com/andrskom/go-redis-replication/rdb"
 	"github.com/andrskom/go-redis-replication/resp"
 )
 
 var ErrSyncStarted = errors.New("sync cmd sent, replication mode enabled")
+var ErrUnexpectedSyncResult = errors.New("unexpected result on SYNC cmd")
 
 // Client work only in one thread, and safe for concurrency
 type Client struct {
@@ -24,6 +26,11 @@ func New(conn *resp.Conn) *Client {
 	}
 }
 
+// GetConn return connection of client, e.g. for REPLCONF ACK by resp.WithAck after Sync
+func (c *Client) GetConn() *resp.Conn {
+	return c.conn
+}
+
 func (c *Client) Del(keys ...string) (*resp.Result, error) {
 	var res *resp.Result
 	var err error
@@ -115,6 +122,19 @@ func (c *Client) Sync() (*bufio.Reader, *resp.Result, error) {
 	return c.conn.GetReader(), res, err
 }
 
+// NewRDBReader return reader of RDB payload, which is sent by master after result of SYNC cmd.
+// Payload is limited by length of bulk string or by eof mark in diskless replication.
+func NewRDBReader(r *bufio.Reader, res *resp.Result) (rdb.PayloadReader, error) {
+	switch {
+	case res.IsEOFMarked():
+		return rdb.NewEOFMarkReader(r, res.GetEOFMark()), nil
+	case res.IsBulkString() && res.GetBulkStringLen() >= 0:
+		return rdb.NewReader(r, uint64(res.GetBulkStringLen())), nil
+	default:
+		return nil, ErrUnexpectedSyncResult
+	}
+}
+
 func (c *Client) Hset(k string, field string, v string) (*resp.Result, error) {
 	var res *resp.Result
 	var err error
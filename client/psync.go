@@ -0,0 +1,115 @@
+package client
+
+import (
+	"bufio"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/andrskom/go-redis-replication/resp"
+)
+
+const (
+	// PSyncUnknownReplID is used in PSYNC to force full resync, when replication id is unknown
+	PSyncUnknownReplID = "?"
+	// PSyncUnknownOffset is used in PSYNC with PSyncUnknownReplID
+	PSyncUnknownOffset = -1
+
+	psyncFullResync      = "FULLRESYNC"
+	psyncContinue        = "CONTINUE"
+	psyncNoMasterSupport = "NOMASTERSUPPORT"
+)
+
+var ErrNoMasterSupport = errors.New("master doesn't support PSYNC, use SYNC")
+var ErrUnexpectedPSyncResult = errors.New("unexpected result on PSYNC cmd")
+
+type PSyncMode int
+
+const (
+	// PSyncModeFullResync means master send RDB and then stream of cmds
+	PSyncModeFullResync PSyncMode = iota
+	// PSyncModeContinue means master continue stream of cmds from requested offset
+	PSyncModeContinue
+)
+
+type PSyncResult struct {
+	Mode PSyncMode
+	// ReplID is replication id of master, for CONTINUE it's requested id if master doesn't send new one
+	ReplID string
+	// Offset is replication offset of master before the first cmd of stream,
+	// for FULLRESYNC it's offset of RDB, for CONTINUE it's requested offset - 1
+	Offset int64
+	// RDB is result with length or eof mark of RDB payload, it's set only for FULLRESYNC
+	RDB *resp.Result
+}
+
+// PSync send PSYNC cmd and read reply of master, reader is ready for reading of RDB payload for FULLRESYNC
+// and for reading of cmds for CONTINUE. Use PSyncUnknownReplID and PSyncUnknownOffset for the first sync,
+// to continue replication use replication id and offset of processed stream + 1.
+func (c *Client) PSync(replID string, offset int64) (*bufio.Reader, *PSyncResult, error) {
+	var res *PSyncResult
+	var err error
+	sfErr := c.safeSyncFunc(func() {
+		if err = c.conn.WriteCmd(resp.NewCmd(resp.CmdPSync, replID, strconv.FormatInt(offset, 10))); err != nil {
+			return
+		}
+		var reply *resp.Result
+		reply, err = c.conn.WaitCmdResult()
+		if err != nil {
+			return
+		}
+		res, err = parsePSyncResult(reply, replID, offset)
+		if reply.IsErr() {
+			// replication isn't started, e.g. client can fallback to SYNC
+			c.syncStarted = false
+		}
+		if err != nil || res.Mode != PSyncModeFullResync {
+			return
+		}
+		res.RDB, err = c.conn.WaitCmdResult()
+	}, true)
+	if sfErr != nil {
+		return nil, nil, sfErr
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return c.conn.GetReader(), res, nil
+}
+
+func parsePSyncResult(reply *resp.Result, replID string, offset int64) (*PSyncResult, error) {
+	if reply.IsErr() {
+		if strings.HasPrefix(reply.GetString(), psyncNoMasterSupport) {
+			return nil, ErrNoMasterSupport
+		}
+		return nil, errors.New(reply.GetString())
+	}
+	if reply.IsBulkString() || reply.IsInt() {
+		return nil, ErrUnexpectedPSyncResult
+	}
+
+	parts := strings.Fields(reply.GetString())
+	if len(parts) == 0 {
+		return nil, ErrUnexpectedPSyncResult
+	}
+	switch parts[0] {
+	case psyncFullResync:
+		if len(parts) != 3 {
+			return nil, ErrUnexpectedPSyncResult
+		}
+		masterOffset, err := strconv.ParseInt(parts[2], 10, 64)
+		if err != nil {
+			return nil, ErrUnexpectedPSyncResult
+		}
+		return &PSyncResult{Mode: PSyncModeFullResync, ReplID: parts[1], Offset: masterOffset}, nil
+	case psyncContinue:
+		res := &PSyncResult{Mode: PSyncModeContinue, ReplID: replID, Offset: offset - 1}
+		if len(parts) > 1 {
+			res.ReplID = parts[1]
+		}
+		return res, nil
+	default:
+		return nil, ErrUnexpectedPSyncResult
+	}
+}
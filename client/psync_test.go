@@ -0,0 +1,131 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrskom/go-redis-replication/resp"
+)
+
+// testConn read replies from r and save written cmds
+type testConn struct {
+	r io.Reader
+	w bytes.Buffer
+}
+
+func newTestConn(replies string) *testConn {
+	return &testConn{r: strings.NewReader(replies)}
+}
+
+func (c *testConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
+
+func (c *testConn) Write(p []byte) (int, error) {
+	return c.w.Write(p)
+}
+
+func TestParsePSyncResult(t *testing.T) {
+	r := require.New(t)
+	type testData struct {
+		reply       string
+		expectedRes *PSyncResult
+		err         error
+	}
+	dp := []testData{
+		{
+			reply:       "+FULLRESYNC 8de1787ba490483314a4d30f1c628bc5025eb761 2345\r\n",
+			expectedRes: &PSyncResult{Mode: PSyncModeFullResync, ReplID: "8de1787ba490483314a4d30f1c628bc5025eb761", Offset: 2345},
+		},
+		{
+			reply:       "+CONTINUE\r\n",
+			expectedRes: &PSyncResult{Mode: PSyncModeContinue, ReplID: "old", Offset: 100},
+		},
+		{
+			reply:       "+CONTINUE newid\r\n",
+			expectedRes: &PSyncResult{Mode: PSyncModeContinue, ReplID: "newid", Offset: 100},
+		},
+		{reply: "-NOMASTERSUPPORT\r\n", err: ErrNoMasterSupport},
+		{reply: "-ERR unknown command 'psync'\r\n", err: errors.New("ERR unknown command 'psync'")},
+		{reply: "+FULLRESYNC\r\n", err: ErrUnexpectedPSyncResult},
+		{reply: "+FULLRESYNC id\r\n", err: ErrUnexpectedPSyncResult},
+		{reply: "+FULLRESYNC id 1 2\r\n", err: ErrUnexpectedPSyncResult},
+		{reply: "+FULLRESYNC id offset\r\n", err: ErrUnexpectedPSyncResult},
+		{reply: "+FULLRESYNC id 1.5\r\n", err: ErrUnexpectedPSyncResult},
+		{reply: "+\r\n", err: ErrUnexpectedPSyncResult},
+		{reply: "+OK\r\n", err: ErrUnexpectedPSyncResult},
+		{reply: ":1\r\n", err: ErrUnexpectedPSyncResult},
+		{reply: "$3\r\n", err: ErrUnexpectedPSyncResult},
+	}
+
+	for k, data := range dp {
+		t.Run(fmt.Sprintf("Data provider #%d", k), func(t *testing.T) {
+			reply, err := resp.NewConn(newTestConn(data.reply)).ReadCmdResult()
+			r.NoError(err)
+			res, err := parsePSyncResult(reply, "old", 101)
+			if data.err != nil {
+				r.Equal(data.err, err)
+				r.Nil(res)
+				return
+			}
+			r.NoError(err)
+			r.Equal(data.expectedRes, res)
+		})
+	}
+}
+
+func TestClient_PSync_GivenFullResync_RDBResult(t *testing.T) {
+	r := require.New(t)
+	mark := "0123456789abcdef0123456789abcdef01234567"
+	conn := newTestConn("+FULLRESYNC id 42\r\n\n\n$EOF:" + mark + "\r\nREDIS")
+	cl := New(resp.NewConn(conn))
+
+	reader, res, err := cl.PSync(PSyncUnknownReplID, PSyncUnknownOffset)
+	r.NoError(err)
+	r.Equal("*3\r\n$5\r\npsync\r\n$1\r\n?\r\n$2\r\n-1\r\n", conn.w.String())
+	r.Equal(PSyncModeFullResync, res.Mode)
+	r.Equal("id", res.ReplID)
+	r.Equal(int64(42), res.Offset)
+	r.True(res.RDB.IsEOFMarked())
+	r.Equal([]byte(mark), res.RDB.GetEOFMark())
+	magic, err := reader.Peek(5)
+	r.NoError(err)
+	r.Equal("REDIS", string(magic))
+
+	_, _, err = cl.PSync("id", 43)
+	r.Equal(ErrSyncStarted, err)
+}
+
+func TestClient_PSync_GivenDecoderOffset_Continue(t *testing.T) {
+	r := require.New(t)
+	cmd := "*1\r\n$4\r\nPING\r\n"
+	dec := resp.NewDecoder(bufio.NewReader(strings.NewReader(cmd)), &resp.LogConsumer{}, resp.WithOffset(42))
+	r.Error(dec.Decode())
+	r.Equal(int64(42+len(cmd)), dec.Offset())
+
+	// replication is continued from the next byte after processed offset
+	conn := newTestConn("+CONTINUE\r\n")
+	_, res, err := New(resp.NewConn(conn)).PSync("id", dec.Offset()+1)
+	r.NoError(err)
+	r.Equal("*3\r\n$5\r\npsync\r\n$2\r\nid\r\n$2\r\n57\r\n", conn.w.String())
+	r.Equal(&PSyncResult{Mode: PSyncModeContinue, ReplID: "id", Offset: dec.Offset()}, res)
+}
+
+func TestClient_PSync_GivenNoMasterSupport_SyncIsAllowed(t *testing.T) {
+	r := require.New(t)
+	cl := New(resp.NewConn(newTestConn("-NOMASTERSUPPORT\r\n$0\r\n")))
+
+	_, _, err := cl.PSync(PSyncUnknownReplID, PSyncUnknownOffset)
+	r.Equal(ErrNoMasterSupport, err)
+
+	_, res, err := cl.Sync()
+	r.NoError(err)
+	r.True(res.IsBulkString())
+}
@@ -0,0 +1,74 @@
+package client
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/andrskom/go-redis-replication/resp"
+)
+
+const (
+	// CapaEOF means replica supports RDB with eof mark in diskless replication
+	CapaEOF = "eof"
+	// CapaPSync2 means replica supports continuation of PSYNC with new replication id
+	CapaPSync2 = "psync2"
+)
+
+// ReplConf is info about replica, which is sent to master before SYNC or PSYNC,
+// master show it in `INFO replication`
+type ReplConf struct {
+	// ListeningPort isn't sent if it's 0
+	ListeningPort int
+	// IPAddress isn't sent if it's empty, master use address of connection then
+	IPAddress    string
+	Capabilities []string
+}
+
+// GetDefaultReplConf return conf with local port of connection as listening port
+func (c *Client) GetDefaultReplConf() ReplConf {
+	return ReplConf{
+		ListeningPort: c.conn.GetLocalPort(),
+		Capabilities:  []string{CapaEOF, CapaPSync2},
+	}
+}
+
+// ReplConf send REPLCONF cmds of handshake, it must be called before Sync or PSync
+func (c *Client) ReplConf(conf ReplConf) error {
+	cmds := make([]resp.Cmd, 0, 3)
+	if conf.ListeningPort != 0 {
+		cmds = append(cmds, resp.NewCmd(resp.CmdReplConf, resp.ReplConfSubCmdListeningPort, strconv.Itoa(conf.ListeningPort)))
+	}
+	if conf.IPAddress != "" {
+		cmds = append(cmds, resp.NewCmd(resp.CmdReplConf, resp.ReplConfSubCmdIPAddress, conf.IPAddress))
+	}
+	if len(conf.Capabilities) > 0 {
+		args := make([]string, 0, 2*len(conf.Capabilities))
+		for _, capa := range conf.Capabilities {
+			args = append(args, resp.ReplConfSubCmdCapa, capa)
+		}
+		cmds = append(cmds, resp.NewCmd(resp.CmdReplConf, args...))
+	}
+
+	var err error
+	sfErr := c.safeFunc(func() {
+		if c.syncStarted {
+			err = ErrSyncStarted
+			return
+		}
+		for _, cmd := range cmds {
+			var res *resp.Result
+			res, err = c.conn.ExecCmd(cmd)
+			if err != nil {
+				return
+			}
+			if !res.IsOk() {
+				err = fmt.Errorf("unexpected result on REPLCONF %s: %s", cmd[1], res.GetString())
+				return
+			}
+		}
+	})
+	if sfErr != nil {
+		return sfErr
+	}
+	return err
+}
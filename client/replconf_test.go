@@ -0,0 +1,100 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrskom/go-redis-replication/resp"
+)
+
+func TestClient_ReplConf_GivenConf_Cmds(t *testing.T) {
+	r := require.New(t)
+	type testData struct {
+		conf         ReplConf
+		replies      string
+		expectedCmds string
+	}
+	dp := []testData{
+		{
+			conf:    ReplConf{ListeningPort: 6380, IPAddress: "10.0.0.1", Capabilities: []string{CapaEOF, CapaPSync2}},
+			replies: "+OK\r\n+OK\r\n+OK\r\n",
+			expectedCmds: "*3\r\n$8\r\nreplconf\r\n$14\r\nlistening-port\r\n$4\r\n6380\r\n" +
+				"*3\r\n$8\r\nreplconf\r\n$10\r\nip-address\r\n$8\r\n10.0.0.1\r\n" +
+				"*5\r\n$8\r\nreplconf\r\n$4\r\ncapa\r\n$3\r\neof\r\n$4\r\ncapa\r\n$6\r\npsync2\r\n",
+		},
+		{
+			conf:         ReplConf{Capabilities: []string{CapaEOF, CapaPSync2}},
+			replies:      "+OK\r\n",
+			expectedCmds: "*5\r\n$8\r\nreplconf\r\n$4\r\ncapa\r\n$3\r\neof\r\n$4\r\ncapa\r\n$6\r\npsync2\r\n",
+		},
+		{
+			conf:         ReplConf{ListeningPort: 6380},
+			replies:      "+OK\r\n",
+			expectedCmds: "*3\r\n$8\r\nreplconf\r\n$14\r\nlistening-port\r\n$4\r\n6380\r\n",
+		},
+		{
+			conf:         ReplConf{IPAddress: "10.0.0.1", Capabilities: []string{CapaEOF}},
+			replies:      "+OK\r\n+OK\r\n",
+			expectedCmds: "*3\r\n$8\r\nreplconf\r\n$10\r\nip-address\r\n$8\r\n10.0.0.1\r\n*3\r\n$8\r\nreplconf\r\n$4\r\ncapa\r\n$3\r\neof\r\n",
+		},
+		{
+			conf:         ReplConf{},
+			expectedCmds: "",
+		},
+	}
+
+	for k, data := range dp {
+		t.Run(fmt.Sprintf("Data provider #%d", k), func(t *testing.T) {
+			conn := newTestConn(data.replies)
+			r.NoError(New(resp.NewConn(conn)).ReplConf(data.conf))
+			r.Equal(data.expectedCmds, conn.w.String())
+		})
+	}
+}
+
+func TestClient_ReplConf_GivenErrReply_Err(t *testing.T) {
+	r := require.New(t)
+	conn := newTestConn("+OK\r\n-ERR Unrecognized REPLCONF option: ip-address\r\n")
+	conf := ReplConf{ListeningPort: 6380, IPAddress: "10.0.0.1", Capabilities: []string{CapaEOF}}
+
+	err := New(resp.NewConn(conn)).ReplConf(conf)
+	r.Equal(errors.New("unexpected result on REPLCONF ip-address: ERR Unrecognized REPLCONF option: ip-address"), err)
+	// capa isn't sent after error
+	r.NotContains(conn.w.String(), "capa")
+}
+
+func TestClient_ReplConf_GivenSyncStarted_ErrSyncStarted(t *testing.T) {
+	r := require.New(t)
+	conn := newTestConn("$0\r\n")
+	cl := New(resp.NewConn(conn))
+	_, _, err := cl.Sync()
+	r.NoError(err)
+	conn.w.Reset()
+
+	r.Equal(ErrSyncStarted, cl.ReplConf(cl.GetDefaultReplConf()))
+	r.Empty(conn.w.String())
+}
+
+func TestClient_GetDefaultReplConf_GivenTCPConn_LocalPort(t *testing.T) {
+	r := require.New(t)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	r.NoError(err)
+	defer l.Close()
+	conn, err := net.Dial("tcp", l.Addr().String())
+	r.NoError(err)
+	defer conn.Close()
+
+	conf := New(resp.NewConn(conn)).GetDefaultReplConf()
+	r.Equal(conn.LocalAddr().(*net.TCPAddr).Port, conf.ListeningPort)
+	r.Equal([]string{CapaEOF, CapaPSync2}, conf.Capabilities)
+}
+
+func TestClient_GetDefaultReplConf_GivenNotTCPConn_WithoutPort(t *testing.T) {
+	r := require.New(t)
+	conf := New(resp.NewConn(newTestConn(""))).GetDefaultReplConf()
+	r.Equal(ReplConf{Capabilities: []string{CapaEOF, CapaPSync2}}, conf)
+}
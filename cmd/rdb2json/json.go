@@ -0,0 +1,233 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"math"
+	"time"
+	"unicode/utf8"
+
+	"github.com/andrskom/go-redis-replication/rdb"
+)
+
+// binaryString is used instead of string for data which isn't valid utf-8
+type binaryString struct {
+	Base64 string `json:"base64"`
+}
+
+type jsonRow struct {
+	DB     uint32      `json:"db"`
+	Key    interface{} `json:"key"`
+	Type   string      `json:"type"`
+	Expiry *int64      `json:"expiry"`
+	Value  interface{} `json:"value"`
+}
+
+type jsonSortedSetEntry struct {
+	Member interface{} `json:"member"`
+	Score  interface{} `json:"score"`
+}
+
+type jsonHashField struct {
+	Field interface{} `json:"field"`
+	Value interface{} `json:"value"`
+}
+
+type jsonStreamEntry struct {
+	ID     string          `json:"id"`
+	Fields []jsonHashField `json:"fields"`
+}
+
+type jsonStreamConsumer struct {
+	Name    interface{} `json:"name"`
+	Pending int         `json:"pending"`
+}
+
+type jsonStreamGroup struct {
+	Name            interface{}          `json:"name"`
+	LastDeliveredID string               `json:"last_delivered_id"`
+	Pending         int                  `json:"pending"`
+	Consumers       []jsonStreamConsumer `json:"consumers"`
+}
+
+type jsonStream struct {
+	Length  uint64            `json:"length"`
+	LastID  string            `json:"last_id"`
+	Entries []jsonStreamEntry `json:"entries"`
+	Groups  []jsonStreamGroup `json:"groups"`
+}
+
+type jsonModule struct {
+	Module string        `json:"module"`
+	EncVer uint64        `json:"enc_ver"`
+	Data   []interface{} `json:"data"`
+}
+
+// JSONConsumer write every row as json object on separate line,
+// write errors are saved and returned by Err, rows after error are skipped
+type JSONConsumer struct {
+	enc *json.Encoder
+	db  uint32
+	n   uint64
+	err error
+}
+
+func NewJSONConsumer(w io.Writer) *JSONConsumer {
+	return &JSONConsumer{enc: json.NewEncoder(w)}
+}
+
+func (c *JSONConsumer) Err() error {
+	return c.err
+}
+
+// Rows return number of written rows
+func (c *JSONConsumer) Rows() uint64 {
+	return c.n
+}
+
+func (c *JSONConsumer) RDBVersion(version string) {
+}
+
+func (c *JSONConsumer) AuxiliaryField(field rdb.AuxiliaryField) {
+}
+
+func (c *JSONConsumer) ResizeDB(dbHashtableSize uint32, expiryHashtableSize uint32) {
+}
+
+func (c *JSONConsumer) SelectDB(db uint32) {
+	c.db = db
+}
+
+func (c *JSONConsumer) Row(row *rdb.Row) {
+	if c.err != nil {
+		return
+	}
+	key := row.RawKey
+	if key.Val == nil {
+		key = rdb.Bytes{Val: []byte(row.Key)}
+	}
+	res := jsonRow{
+		DB:    c.db,
+		Key:   jsonBytes(key.Val),
+		Type:  row.Kind().String(),
+		Value: jsonValue(row.Value),
+	}
+	if row.Expiry != nil {
+		ms := row.Expiry.Unix()*1000 + int64(row.Expiry.Nanosecond())/int64(time.Millisecond)
+		res.Expiry = &ms
+	}
+	if err := c.enc.Encode(res); err != nil {
+		c.err = err
+		return
+	}
+	c.n++
+}
+
+func (c *JSONConsumer) End(crc []byte) {
+}
+
+// jsonBytes return string for utf-8 data and base64 object for binary data
+func jsonBytes(b []byte) interface{} {
+	if utf8.Valid(b) {
+		return string(b)
+	}
+	return binaryString{Base64: base64.StdEncoding.EncodeToString(b)}
+}
+
+func jsonBytesList(list []rdb.Bytes) []interface{} {
+	res := make([]interface{}, 0, len(list))
+	for _, b := range list {
+		res = append(res, jsonBytes(b.Val))
+	}
+	return res
+}
+
+// jsonFloat return number or string for NaN and infinities, json doesn't support them
+func jsonFloat(f float64) interface{} {
+	switch {
+	case math.IsNaN(f):
+		return "nan"
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	}
+	return f
+}
+
+func jsonValue(v rdb.Value) interface{} {
+	switch val := v.(type) {
+	case *rdb.StringValue:
+		return jsonBytes(val.Val.Val)
+	case *rdb.ListValue:
+		return jsonBytesList(val.Elements)
+	case *rdb.SetValue:
+		return jsonBytesList(val.Members)
+	case *rdb.SortedSetValue:
+		res := make([]jsonSortedSetEntry, 0, len(val.Entries))
+		for _, e := range val.Entries {
+			res = append(res, jsonSortedSetEntry{Member: jsonBytes(e.Member.Val), Score: jsonFloat(e.Score)})
+		}
+		return res
+	case *rdb.HashValue:
+		res := make([]jsonHashField, 0, len(val.Fields))
+		for _, f := range val.Fields {
+			res = append(res, jsonHashField{Field: jsonBytes(f.Field.Val), Value: jsonBytes(f.Value.Val)})
+		}
+		return res
+	case *rdb.Stream:
+		return jsonStreamValue(val)
+	case *rdb.ModuleValue:
+		res := jsonModule{Module: val.Name, EncVer: val.EncVer, Data: make([]interface{}, 0, len(val.Data))}
+		for _, d := range val.Data {
+			switch dv := d.(type) {
+			case rdb.Bytes:
+				res.Data = append(res.Data, jsonBytes(dv.Val))
+			case float32:
+				res.Data = append(res.Data, jsonFloat(float64(dv)))
+			case float64:
+				res.Data = append(res.Data, jsonFloat(dv))
+			default:
+				res.Data = append(res.Data, dv)
+			}
+		}
+		return res
+	}
+	return nil
+}
+
+func jsonStreamValue(s *rdb.Stream) jsonStream {
+	res := jsonStream{
+		Length:  s.Length,
+		LastID:  s.LastID.String(),
+		Entries: make([]jsonStreamEntry, 0, len(s.Entries)),
+		Groups:  make([]jsonStreamGroup, 0, len(s.Groups)),
+	}
+	for _, e := range s.Entries {
+		entry := jsonStreamEntry{ID: e.ID.String(), Fields: make([]jsonHashField, 0, len(e.Fields))}
+		for _, f := range e.Fields {
+			entry.Fields = append(entry.Fields, jsonHashField{
+				Field: jsonBytes(f.Field.Val),
+				Value: jsonBytes(f.Value.Val),
+			})
+		}
+		res.Entries = append(res.Entries, entry)
+	}
+	for _, g := range s.Groups {
+		group := jsonStreamGroup{
+			Name:            jsonBytes([]byte(g.Name)),
+			LastDeliveredID: g.LastDeliveredID.String(),
+			Pending:         len(g.Pending),
+			Consumers:       make([]jsonStreamConsumer, 0, len(g.Consumers)),
+		}
+		for _, consumer := range g.Consumers {
+			group.Consumers = append(group.Consumers, jsonStreamConsumer{
+				Name:    jsonBytes([]byte(consumer.Name)),
+				Pending: len(consumer.Pending),
+			})
+		}
+		res.Groups = append(res.Groups, group)
+	}
+	return res
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrskom/go-redis-replication/rdb"
+)
+
+func TestJSONConsumer_GivenRows_JSONLines(t *testing.T) {
+	r := require.New(t)
+	buf := &bytes.Buffer{}
+	c := NewJSONConsumer(buf)
+	expiry := time.Unix(1700000000, 0)
+
+	c.SelectDB(3)
+	c.Row(&rdb.Row{
+		Key:    "str",
+		RawKey: rdb.Bytes{Val: []byte("str")},
+		Expiry: &expiry,
+		Value:  &rdb.StringValue{Val: rdb.Bytes{Val: []byte("val")}},
+	})
+	c.Row(&rdb.Row{
+		Key:    "\xff\x00",
+		RawKey: rdb.Bytes{Val: []byte{0xff, 0x00}},
+		Value:  &rdb.ListValue{Elements: []rdb.Bytes{{Val: []byte("a")}, {Val: []byte{0xfe}}}},
+	})
+	c.Row(&rdb.Row{
+		Key: "zset",
+		Value: &rdb.SortedSetValue{Entries: []rdb.SortedSetEntry{
+			{Member: rdb.Bytes{Val: []byte("a")}, Score: 1.5},
+			{Member: rdb.Bytes{Val: []byte("b")}, Score: math.Inf(1)},
+		}},
+	})
+	c.Row(&rdb.Row{
+		Key:   "hash",
+		Value: &rdb.HashValue{Fields: []rdb.HashField{{Field: rdb.Bytes{Val: []byte("f")}, Value: rdb.NewIntBytes(1)}}},
+	})
+	r.NoError(c.Err())
+	r.Equal(uint64(4), c.Rows())
+
+	r.Equal(strings.Join([]string{
+		`{"db":3,"key":"str","type":"string","expiry":1700000000000,"value":"val"}`,
+		`{"db":3,"key":{"base64":"/wA="},"type":"list","expiry":null,"value":["a",{"base64":"/g=="}]}`,
+		`{"db":3,"key":"zset","type":"zset","expiry":null,"value":[{"member":"a","score":1.5},{"member":"b","score":"inf"}]}`,
+		`{"db":3,"key":"hash","type":"hash","expiry":null,"value":[{"field":"f","value":"1"}]}`,
+	}, "\n")+"\n", buf.String())
+}
+
+func TestJSONConsumer_GivenRDB_ObjectPerKey(t *testing.T) {
+	r := require.New(t)
+	data, err := ioutil.ReadFile("../../e2e/rdb/rdb")
+	r.NoError(err)
+	buf := &bytes.Buffer{}
+	c := NewJSONConsumer(buf)
+
+	r.NoError(rdb.NewDecoder(bytes.NewBuffer(data), c).Decode())
+	r.NoError(c.Err())
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	r.Len(lines, int(c.Rows()))
+	for _, line := range lines {
+		row := map[string]interface{}{}
+		r.NoError(json.Unmarshal([]byte(line), &row))
+		r.Contains(row, "db")
+		r.Contains(row, "key")
+		r.Contains(row, "type")
+		r.Contains(row, "expiry")
+		r.Contains(row, "value")
+	}
+}
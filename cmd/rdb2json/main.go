@@ -0,0 +1,73 @@
+// Command rdb2json export keys of RDB file as json lines, one object per key:
+//
+//	{"db":0,"key":"k","type":"hash","expiry":1700000000000,"value":[{"field":"f","value":"v"}]}
+//
+// Expiry is unix time in milliseconds or null. Strings which aren't valid utf-8
+// are written as objects {"base64":"..."}.
+//
+// Usage:
+//
+//	rdb2json [-o output.jsonl] [dump.rdb]
+//
+// RDB is read from stdin if file isn't passed or it's "-".
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/andrskom/go-redis-replication/rdb"
+)
+
+func main() {
+	output := flag.String("o", "", "output file, stdout by default")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [-o output.jsonl] [dump.rdb]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(flag.Arg(0), *output); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(input string, output string) error {
+	var in io.Reader = os.Stdin
+	if input != "" && input != "-" {
+		f, err := os.Open(input)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	var out io.Writer = os.Stdout
+	if output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	w := bufio.NewWriter(out)
+	c := NewJSONConsumer(w)
+	if err := rdb.NewDecoder(bufio.NewReader(in), c).Decode(); err != nil {
+		return err
+	}
+	if err := c.Err(); err != nil {
+		return err
+	}
+	return w.Flush()
+}
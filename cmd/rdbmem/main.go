@@ -0,0 +1,76 @@
+// Command rdbmem estimate memory usage of keys in RDB file and print summary
+// aggregated by type, encoding and key prefix with the biggest keys.
+//
+// Usage:
+//
+//	rdbmem [-top 10] [-sep :] [-depth 1] [-csv keys.csv] [dump.rdb]
+//
+// RDB is read from stdin if file isn't passed or it's "-".
+// Usage of every key is written to CSV file if -csv is passed.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/andrskom/go-redis-replication/memory"
+)
+
+func main() {
+	top := flag.Int("top", memory.DefaultTopN, "number of the biggest keys")
+	sep := flag.String("sep", memory.DefaultPrefixSeparator, "separator of key parts")
+	depth := flag.Int("depth", memory.DefaultPrefixDepth, "number of key parts in prefix")
+	csvPath := flag.String("csv", "", "file for CSV with usage of every key")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [dump.rdb]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	opts := []memory.Option{memory.WithTopN(*top), memory.WithPrefix(*sep, *depth)}
+	if err := run(flag.Arg(0), *csvPath, opts); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(input string, csvPath string, opts []memory.Option) error {
+	var in io.Reader = os.Stdin
+	if input != "" && input != "-" {
+		f, err := os.Open(input)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	var csvWriter *bufio.Writer
+	if csvPath != "" {
+		f, err := os.Create(csvPath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		csvWriter = bufio.NewWriter(f)
+		opts = append(opts, memory.WithKeysCSV(csvWriter))
+	}
+
+	report, err := memory.Analyze(bufio.NewReader(in), opts...)
+	if err != nil {
+		return err
+	}
+	if csvWriter != nil {
+		if err := csvWriter.Flush(); err != nil {
+			return err
+		}
+	}
+	return report.WriteSummary(os.Stdout)
+}
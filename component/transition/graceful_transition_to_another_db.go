@@ -97,20 +97,27 @@ func (c *GracefulTransitionToAnotherDb) Run() error {
 		return errors.New("can't select command db for sync")
 	}
 
+	if err := c.syncClient.ReplConf(c.syncClient.GetDefaultReplConf()); err != nil {
+		return err
+	}
 	reader, res, err := c.syncClient.Sync()
 	if err != nil {
 		return err
 	}
-	if !res.IsBulkString() {
+	rdbReader, err := client.NewRDBReader(reader, res)
+	if err != nil {
 		log.Println(res.String())
-		return errors.New("unexpected result on SYNC cmd")
+		return err
 	}
 	log.Println("Decode started")
-	if err := rdb.NewDecoder(reader, c.rdbConsumer).Decode(); err != nil {
+	if err := rdb.NewDecoder(rdbReader, c.rdbConsumer).Decode(); err != nil {
+		return err
+	}
+	if err := rdbReader.CheckEnd(); err != nil {
 		return err
 	}
 
-	respDecoder := resp.NewDecoder(reader, c)
+	respDecoder := resp.NewDecoder(reader, c, resp.WithAck(c.syncClient.GetConn(), resp.DefaultAckInterval))
 	go func() {
 		if err := respDecoder.Decode(); err != nil {
 			c.errCh <- err
@@ -43,8 +43,11 @@ func TestHashMapWithoutZiplist(t *testing.T) {
 	r.NoError(err)
 	r.True(res.IsBulkString())
 	log.Println(res.GetBulkStringLen())
-	dec := rdb.NewDecoder(reader, &rdb.LogConsumer{})
+	rdbReader, err := client.NewRDBReader(reader, res)
+	r.NoError(err)
+	dec := rdb.NewDecoder(rdbReader, &rdb.LogConsumer{})
 	r.NoError(dec.Decode())
+	r.NoError(rdbReader.CheckEnd())
 	r.NoError(resp.NewDecoder(reader, &resp.LogConsumer{}).Decode())
 }
 
@@ -86,7 +89,10 @@ func TestHashMapWitZiplist(t *testing.T) {
 	r.NoError(err)
 	r.True(res.IsBulkString())
 	log.Println(res.GetBulkStringLen())
-	dec := rdb.NewDecoder(reader, &rdb.LogConsumer{})
+	rdbReader, err := client.NewRDBReader(reader, res)
+	r.NoError(err)
+	dec := rdb.NewDecoder(rdbReader, &rdb.LogConsumer{})
 	r.NoError(dec.Decode())
+	r.NoError(rdbReader.CheckEnd())
 	r.NoError(resp.NewDecoder(reader, &resp.LogConsumer{}).Decode())
 }
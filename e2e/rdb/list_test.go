@@ -1,8 +1,115 @@
 package rdb
 
 import (
+	"log"
+	"strings"
 	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrskom/go-redis-replication/client"
+	"github.com/andrskom/go-redis-replication/e2e/util"
+	"github.com/andrskom/go-redis-replication/rdb"
+	"github.com/andrskom/go-redis-replication/resp"
 )
 
+// rowsConsumer collect rows by key
+type rowsConsumer struct {
+	rdb.LogConsumer
+	rows map[string]*rdb.Row
+}
+
+func (c *rowsConsumer) Row(row *rdb.Row) {
+	c.rows[row.Key] = row
+}
+
+// reversed return elements in order of list after LPUSH
+func reversed(vals []string) []string {
+	res := make([]string, 0, len(vals))
+	for i := len(vals) - 1; i >= 0; i-- {
+		res = append(res, vals[i])
+	}
+	return res
+}
+
 func TestList(t *testing.T) {
+	r := require.New(t)
+	conn, err := util.GetRedisConn()
+	r.NoError(err)
+	cl := client.New(resp.NewConn(conn))
+
+	// every encoding of ziplist entry: 6, 14 and 32 bit string lengths, 4 bit, 8, 16, 24, 32 and 64 bit ints
+	vals := []string{
+		"a",
+		strings.Repeat("b", 300),
+		strings.Repeat("c", 20000),
+		"1",
+		"-100",
+		"32767",
+		"-8388608",
+		"2147483647",
+		"-9223372036854775808",
+		"1.5",
+	}
+	lists := map[string][]string{}
+	{
+		res, err := cl.Select(0)
+		r.NoError(err)
+		r.True(res.IsOk(), "Bad result", res.String())
+		res, err = cl.FlushDB()
+		r.NoError(err)
+		r.False(res.IsErr(), "Bad result", res.String())
+
+		// one quicklist node
+		res, err = cl.ConfigSet(resp.ConfigKeyListMaxZiplistSize, "-2")
+		r.NoError(err)
+		r.True(res.IsOk(), "Bad result", res.String())
+		res, err = cl.LPush("list:single_node", vals[0], vals[3:]...)
+		r.NoError(err)
+		r.False(res.IsErr(), "Bad result", res.String())
+		lists["list:single_node"] = reversed(append([]string{vals[0]}, vals[3:]...))
+
+		// one entry in every quicklist node, big strings are in separate nodes too
+		res, err = cl.ConfigSet(resp.ConfigKeyListMaxZiplistSize, "1")
+		r.NoError(err)
+		r.True(res.IsOk(), "Bad result", res.String())
+		res, err = cl.LPush("list:many_nodes", vals[0], vals[1:]...)
+		r.NoError(err)
+		r.False(res.IsErr(), "Bad result", res.String())
+		lists["list:many_nodes"] = reversed(vals)
+
+		res, err = cl.ConfigSet(resp.ConfigKeyListMaxZiplistSize, "-2")
+		r.NoError(err)
+		r.True(res.IsOk(), "Bad result", res.String())
+		long := make([]string, 0, 1000)
+		for i := 0; i < 1000; i++ {
+			long = append(long, vals[i%len(vals)]+"_"+strings.Repeat("d", i%100))
+		}
+		res, err = cl.LPush("list:long", long[0], long[1:]...)
+		r.NoError(err)
+		r.False(res.IsErr(), "Bad result", res.String())
+		lists["list:long"] = reversed(long)
+	}
+
+	reader, res, err := cl.Sync()
+	r.NoError(err)
+	r.True(res.IsBulkString())
+	log.Println(res.GetBulkStringLen())
+	rdbReader, err := client.NewRDBReader(reader, res)
+	r.NoError(err)
+	c := &rowsConsumer{rows: map[string]*rdb.Row{}}
+	dec := rdb.NewDecoder(rdbReader, c)
+	r.NoError(dec.Decode())
+	r.NoError(rdbReader.CheckEnd())
+
+	r.Len(c.rows, len(lists))
+	for key, expected := range lists {
+		row, ok := c.rows[key]
+		r.True(ok, "Row isn't found", key)
+		r.Equal(rdb.ValueTypeListQuicklist, row.Type, key)
+		r.Equal(rdb.KindList, row.Kind(), key)
+		list, ok := row.AsList()
+		r.True(ok, key)
+		r.Equal(expected, list.Strings(), key)
+	}
 }
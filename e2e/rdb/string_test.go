@@ -61,6 +61,9 @@ func TestString(t *testing.T) {
 	r.NoError(err)
 	r.True(res.IsBulkString())
 	log.Println(res.GetBulkStringLen())
-	dec := rdb.NewDecoder(reader, &rdb.LogConsumer{})
+	rdbReader, err := client.NewRDBReader(reader, res)
+	r.NoError(err)
+	dec := rdb.NewDecoder(rdbReader, &rdb.LogConsumer{})
 	r.NoError(dec.Decode())
+	r.NoError(rdbReader.CheckEnd())
 }
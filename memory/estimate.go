@@ -0,0 +1,185 @@
+package memory
+
+import (
+	"math/bits"
+
+	"github.com/andrskom/go-redis-replication/rdb"
+)
+
+// Sizes of redis structures on 64 bit platform, they are used for estimation only
+const (
+	robjSize           = 16
+	dictSize           = 96
+	dictEntrySize      = 24
+	listSize           = 48
+	listNodeSize       = 24
+	quicklistSize      = 40
+	quicklistNodeSize  = 32
+	quicklistFill      = 128
+	skiplistSize       = 32
+	skiplistNodeSize   = 32
+	skiplistLevelSize  = 16
+	embstrMaxLen       = 44
+	dictMinBucketCount = 4
+)
+
+// mallocSize round size of allocation up to size class of jemalloc
+func mallocSize(n uint64) uint64 {
+	switch {
+	case n == 0:
+		return 0
+	case n <= 8:
+		return 8
+	case n <= 128:
+		return (n + 15) &^ 15
+	}
+	// there are 4 size classes between powers of 2
+	step := (uint64(1) << uint(bits.Len64(n-1)-1)) / 4
+	return (n + step - 1) / step * step
+}
+
+// sdsSize return size of allocated sds string with header and terminator
+func sdsSize(l uint64) uint64 {
+	var header uint64
+	switch {
+	case l < 1<<8:
+		header = 3
+	case l < 1<<16:
+		header = 5
+	case l < 1<<32:
+		header = 9
+	default:
+		header = 17
+	}
+	return mallocSize(header + l + 1)
+}
+
+// stringObjectSize return size of string object with value, ints are saved in pointer of object
+func stringObjectSize(b rdb.Bytes) uint64 {
+	if b.IsInt {
+		if _, err := b.Int(); err == nil {
+			return robjSize
+		}
+	}
+	l := uint64(len(b.Val))
+	if l <= embstrMaxLen {
+		return mallocSize(robjSize + 3 + l + 1)
+	}
+	return robjSize + sdsSize(l)
+}
+
+func dictOverhead(n uint64) uint64 {
+	buckets := uint64(dictMinBucketCount)
+	for buckets < n {
+		buckets *= 2
+	}
+	return dictSize + mallocSize(buckets*8) + n*mallocSize(dictEntrySize)
+}
+
+func listpackSize(elements []rdb.Bytes) uint64 {
+	return mallocSize(uint64(len(rdb.EncodeListpack(elements))))
+}
+
+// estimateKey return estimated memory of key with value in redis
+func estimateKey(row *rdb.Row, key rdb.Bytes, serialized uint64) uint64 {
+	res := mallocSize(dictEntrySize) + sdsSize(uint64(len(key.Val)))
+	if row.Expiry != nil {
+		// expires dict shares sds of key
+		res += mallocSize(dictEntrySize)
+	}
+	return res + estimateValue(row, serialized)
+}
+
+// estimateValue return estimated memory of value with its object, compact encodings are estimated
+// by size of listpack with the same elements, streams and modules by serialized size
+func estimateValue(row *rdb.Row, serialized uint64) uint64 {
+	switch v := row.Value.(type) {
+	case *rdb.StringValue:
+		return stringObjectSize(v.Val)
+	case *rdb.ListValue:
+		n := uint64(len(v.Elements))
+		if row.Type == rdb.ValueTypeList {
+			res := uint64(robjSize + listSize)
+			for _, el := range v.Elements {
+				res += mallocSize(listNodeSize) + stringObjectSize(el)
+			}
+			return res
+		}
+		nodes := (n + quicklistFill - 1) / quicklistFill
+		res := robjSize + quicklistSize + nodes*mallocSize(quicklistNodeSize)
+		for i := uint64(0); i < n; i += quicklistFill {
+			end := i + quicklistFill
+			if end > n {
+				end = n
+			}
+			res += listpackSize(v.Elements[i:end])
+		}
+		return res
+	case *rdb.SetValue:
+		switch row.Type {
+		case rdb.ValueTypeIntset:
+			ints := make([]int64, 0, len(v.Members))
+			for _, m := range v.Members {
+				i, _ := m.Int()
+				ints = append(ints, i)
+			}
+			return robjSize + mallocSize(uint64(len(rdb.EncodeIntset(ints))))
+		case rdb.ValueTypeSetListpack:
+			return robjSize + listpackSize(v.Members)
+		}
+		res := robjSize + dictOverhead(uint64(len(v.Members)))
+		for _, m := range v.Members {
+			res += sdsSize(uint64(len(m.Val)))
+		}
+		return res
+	case *rdb.SortedSetValue:
+		if row.Type == rdb.ValueTypeSortedSetZiplist || row.Type == rdb.ValueTypeSortedSetListpack {
+			elements := make([]rdb.Bytes, 0, len(v.Entries)*2)
+			for _, e := range v.Entries {
+				// score is saved as string in listpack, 8 bytes is average size
+				elements = append(elements, e.Member, rdb.Bytes{Val: make([]byte, 8)})
+			}
+			return robjSize + listpackSize(elements)
+		}
+		res := robjSize + dictOverhead(uint64(len(v.Entries))) + skiplistSize
+		for _, e := range v.Entries {
+			res += mallocSize(skiplistNodeSize+skiplistLevelSize) + sdsSize(uint64(len(e.Member.Val)))
+		}
+		return res
+	case *rdb.HashValue:
+		if row.Type != rdb.ValueTypeHash {
+			elements := make([]rdb.Bytes, 0, len(v.Fields)*2)
+			for _, f := range v.Fields {
+				elements = append(elements, f.Field, f.Value)
+			}
+			return robjSize + listpackSize(elements)
+		}
+		res := robjSize + dictOverhead(uint64(len(v.Fields)))
+		for _, f := range v.Fields {
+			res += sdsSize(uint64(len(f.Field.Val))) + sdsSize(uint64(len(f.Value.Val)))
+		}
+		return res
+	}
+	return robjSize + serialized
+}
+
+// elementsCount return length of string or number of elements of collection
+func elementsCount(v rdb.Value) uint64 {
+	switch val := v.(type) {
+	case *rdb.StringValue:
+		return uint64(len(val.Val.Val))
+	case *rdb.ListValue:
+		return uint64(len(val.Elements))
+	case *rdb.SetValue:
+		return uint64(len(val.Members))
+	case *rdb.SortedSetValue:
+		return uint64(len(val.Entries))
+	case *rdb.HashValue:
+		return uint64(len(val.Fields))
+	case *rdb.Stream:
+		return val.Length
+	case *rdb.ModuleValue:
+		return uint64(len(val.Data))
+	}
+	return 0
+}
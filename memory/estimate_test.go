@@ -0,0 +1,60 @@
+package memory
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrskom/go-redis-replication/rdb"
+)
+
+func TestMallocSize(t *testing.T) {
+	r := require.New(t)
+	type testData struct {
+		n           uint64
+		expectedRes uint64
+	}
+	dp := []testData{
+		{n: 0, expectedRes: 0},
+		{n: 1, expectedRes: 8},
+		{n: 9, expectedRes: 16},
+		{n: 100, expectedRes: 112},
+		{n: 128, expectedRes: 128},
+		{n: 129, expectedRes: 160},
+		{n: 256, expectedRes: 256},
+		{n: 257, expectedRes: 320},
+		{n: 1025, expectedRes: 1280},
+	}
+
+	for k, data := range dp {
+		t.Run(fmt.Sprintf("Data provider #%d", k), func(t *testing.T) {
+			r.Equal(data.expectedRes, mallocSize(data.n))
+		})
+	}
+}
+
+func TestSdsSize(t *testing.T) {
+	r := require.New(t)
+	r.Equal(uint64(8), sdsSize(1))
+	r.Equal(uint64(320), sdsSize(300))
+}
+
+func TestStringObjectSize(t *testing.T) {
+	r := require.New(t)
+	r.Equal(uint64(robjSize), stringObjectSize(rdb.NewIntBytes(100)))
+	// embstr is allocated with object
+	r.Equal(uint64(32), stringObjectSize(rdb.Bytes{Val: []byte("abc")}))
+	r.Equal(uint64(robjSize+64), stringObjectSize(rdb.Bytes{Val: make([]byte, 45)}))
+}
+
+func TestEstimateValue_GivenCompactEncoding_LessThanPlain(t *testing.T) {
+	r := require.New(t)
+	value := &rdb.HashValue{Fields: []rdb.HashField{
+		{Field: rdb.Bytes{Val: []byte("a")}, Value: rdb.Bytes{Val: []byte("1")}},
+		{Field: rdb.Bytes{Val: []byte("b")}, Value: rdb.Bytes{Val: []byte("2")}},
+	}}
+	compact := estimateValue(&rdb.Row{Type: rdb.ValueTypeHashListpack, Value: value}, 0)
+	plain := estimateValue(&rdb.Row{Type: rdb.ValueTypeHash, Value: value}, 0)
+	r.True(compact < plain)
+}
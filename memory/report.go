@@ -0,0 +1,334 @@
+// Package memory estimate memory usage of keys in redis by RDB and build report
+// aggregated by key prefix, type and encoding with the biggest keys.
+package memory
+
+import (
+	"container/heap"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/andrskom/go-redis-replication/rdb"
+)
+
+const (
+	DefaultTopN            = 10
+	DefaultPrefixSeparator = ":"
+	DefaultPrefixDepth     = 1
+
+	// NoPrefix is used for keys without separator
+	NoPrefix = "<none>"
+)
+
+var csvHeader = []string{"database", "key", "type", "encoding", "memory", "serialized", "elements", "expiry"}
+
+// KeyUsage is estimated memory usage of one key
+type KeyUsage struct {
+	DB       uint32
+	Key      string
+	Kind     rdb.Kind
+	Encoding rdb.ValueType
+	// Memory is estimated size in memory of redis
+	Memory uint64
+	// Serialized is size of key with value in RDB, compressed strings are counted by compressed length
+	Serialized uint64
+	// Elements is number of elements of collection or length of string
+	Elements uint64
+	Expiry   *time.Time
+}
+
+type Stats struct {
+	Keys       uint64
+	Memory     uint64
+	Serialized uint64
+	Elements   uint64
+}
+
+func (s *Stats) add(k *KeyUsage) {
+	s.Keys++
+	s.Memory += k.Memory
+	s.Serialized += k.Serialized
+	s.Elements += k.Elements
+}
+
+type Report struct {
+	Total      Stats
+	ByType     map[string]*Stats
+	ByEncoding map[string]*Stats
+	ByPrefix   map[string]*Stats
+	// TopKeys are the biggest keys by memory in descending order
+	TopKeys []*KeyUsage
+}
+
+type config struct {
+	topN      int
+	separator string
+	depth     int
+	keysCSV   io.Writer
+}
+
+type Option func(c *config)
+
+// WithTopN set number of the biggest keys in report
+func WithTopN(n int) Option {
+	return func(c *config) {
+		c.topN = n
+	}
+}
+
+// WithPrefix set separator of key parts and number of parts in prefix
+func WithPrefix(separator string, depth int) Option {
+	return func(c *config) {
+		c.separator = separator
+		c.depth = depth
+	}
+}
+
+// WithKeysCSV write usage of every key as CSV while RDB is read
+func WithKeysCSV(w io.Writer) Option {
+	return func(c *config) {
+		c.keysCSV = w
+	}
+}
+
+// Analyze read RDB and build report
+func Analyze(r rdb.ByteReader, opts ...Option) (*Report, error) {
+	cfg := config{topN: DefaultTopN, separator: DefaultPrefixSeparator, depth: DefaultPrefixDepth}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	cr := &countingReader{r: r}
+	c := &collector{
+		cfg: cfg,
+		r:   cr,
+		report: &Report{
+			ByType:     make(map[string]*Stats),
+			ByEncoding: make(map[string]*Stats),
+			ByPrefix:   make(map[string]*Stats),
+		},
+	}
+	if cfg.keysCSV != nil {
+		c.csv = csv.NewWriter(cfg.keysCSV)
+		c.writeCSV(csvHeader)
+	}
+	if err := rdb.NewDecoder(cr, c).Decode(); err != nil {
+		return nil, err
+	}
+	if c.csv != nil {
+		c.csv.Flush()
+		c.setErr(c.csv.Error())
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	c.report.TopKeys = make([]*KeyUsage, len(c.top))
+	for i := len(c.top) - 1; i >= 0; i-- {
+		c.report.TopKeys[i] = heap.Pop(&c.top).(*KeyUsage)
+	}
+	return c.report, nil
+}
+
+// WriteSummary write report as text tables
+func (r *Report) WriteSummary(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "Keys:\t%d\n", r.Total.Keys)
+	fmt.Fprintf(tw, "Memory:\t%d\n", r.Total.Memory)
+	fmt.Fprintf(tw, "Serialized:\t%d\n", r.Total.Serialized)
+	fmt.Fprintf(tw, "Elements:\t%d\n", r.Total.Elements)
+	writeStats(tw, "TYPE", r.ByType)
+	writeStats(tw, "ENCODING", r.ByEncoding)
+	writeStats(tw, "PREFIX", r.ByPrefix)
+
+	fmt.Fprintf(tw, "\nDB\tKEY\tTYPE\tENCODING\tMEMORY\tSERIALIZED\tELEMENTS\n")
+	for _, k := range r.TopKeys {
+		fmt.Fprintf(tw, "%d\t%q\t%s\t%s\t%d\t%d\t%d\n", k.DB, k.Key, k.Kind, k.Encoding, k.Memory, k.Serialized, k.Elements)
+	}
+	return tw.Flush()
+}
+
+// writeStats write stats sorted by memory in descending order
+func writeStats(w io.Writer, title string, stats map[string]*Stats) {
+	names := make([]string, 0, len(stats))
+	for name := range stats {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if stats[names[i]].Memory != stats[names[j]].Memory {
+			return stats[names[i]].Memory > stats[names[j]].Memory
+		}
+		return names[i] < names[j]
+	})
+	fmt.Fprintf(w, "\n%s\tKEYS\tMEMORY\tSERIALIZED\tELEMENTS\n", title)
+	for _, name := range names {
+		s := stats[name]
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\n", name, s.Keys, s.Memory, s.Serialized, s.Elements)
+	}
+}
+
+// collector is Consumer, which calculate usage of keys,
+// serialized size of row is number of bytes read by decoder after previous event
+type collector struct {
+	cfg    config
+	r      *countingReader
+	last   uint64
+	db     uint32
+	report *Report
+	top    keyHeap
+	csv    *csv.Writer
+	err    error
+}
+
+func (c *collector) RDBVersion(version string) {
+	c.last = c.r.n
+}
+
+func (c *collector) AuxiliaryField(field rdb.AuxiliaryField) {
+	c.last = c.r.n
+}
+
+func (c *collector) ResizeDB(dbHashtableSize uint32, expiryHashtableSize uint32) {
+	c.last = c.r.n
+}
+
+func (c *collector) SelectDB(db uint32) {
+	c.last = c.r.n
+	c.db = db
+}
+
+func (c *collector) Row(row *rdb.Row) {
+	serialized := c.r.n - c.last
+	c.last = c.r.n
+
+	key := row.RawKey
+	if key.Val == nil {
+		key = rdb.Bytes{Val: []byte(row.Key)}
+	}
+	k := &KeyUsage{
+		DB:         c.db,
+		Key:        string(key.Val),
+		Kind:       row.Kind(),
+		Encoding:   row.Type,
+		Memory:     estimateKey(row, key, serialized),
+		Serialized: serialized,
+		Elements:   elementsCount(row.Value),
+		Expiry:     row.Expiry,
+	}
+
+	c.report.Total.add(k)
+	addStats(c.report.ByType, k.Kind.String(), k)
+	addStats(c.report.ByEncoding, k.Encoding.String(), k)
+	addStats(c.report.ByPrefix, c.prefix(k.Key), k)
+
+	if c.cfg.topN > 0 {
+		if len(c.top) < c.cfg.topN {
+			heap.Push(&c.top, k)
+		} else if c.top[0].Memory < k.Memory {
+			c.top[0] = k
+			heap.Fix(&c.top, 0)
+		}
+	}
+
+	if c.csv != nil {
+		expiry := ""
+		if k.Expiry != nil {
+			ms := k.Expiry.Unix()*1000 + int64(k.Expiry.Nanosecond())/int64(time.Millisecond)
+			expiry = strconv.FormatInt(ms, 10)
+		}
+		c.writeCSV([]string{
+			strconv.FormatUint(uint64(k.DB), 10),
+			k.Key,
+			k.Kind.String(),
+			k.Encoding.String(),
+			strconv.FormatUint(k.Memory, 10),
+			strconv.FormatUint(k.Serialized, 10),
+			strconv.FormatUint(k.Elements, 10),
+			expiry,
+		})
+	}
+}
+
+// ModuleAux is implemented to skip aux data of modules, it isn't part of the next row
+func (c *collector) ModuleAux(aux *rdb.ModuleAux) {
+	c.last = c.r.n
+}
+
+// Function is implemented to skip function libraries, they aren't part of the next row
+func (c *collector) Function(code []byte) {
+	c.last = c.r.n
+}
+
+func (c *collector) End(crc []byte) {
+}
+
+func (c *collector) prefix(key string) string {
+	if c.cfg.separator == "" || c.cfg.depth <= 0 {
+		return NoPrefix
+	}
+	parts := strings.SplitN(key, c.cfg.separator, c.cfg.depth+1)
+	if len(parts) <= c.cfg.depth {
+		return NoPrefix
+	}
+	return strings.Join(parts[:c.cfg.depth], c.cfg.separator) + c.cfg.separator
+}
+
+func (c *collector) writeCSV(record []string) {
+	if c.err != nil {
+		return
+	}
+	c.setErr(c.csv.Write(record))
+}
+
+func (c *collector) setErr(err error) {
+	if c.err == nil {
+		c.err = err
+	}
+}
+
+func addStats(stats map[string]*Stats, name string, k *KeyUsage) {
+	s, ok := stats[name]
+	if !ok {
+		s = &Stats{}
+		stats[name] = s
+	}
+	s.add(k)
+}
+
+// keyHeap is min heap by memory, it keeps the biggest keys
+type keyHeap []*KeyUsage
+
+func (h keyHeap) Len() int            { return len(h) }
+func (h keyHeap) Less(i, j int) bool  { return h[i].Memory < h[j].Memory }
+func (h keyHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *keyHeap) Push(x interface{}) { *h = append(*h, x.(*KeyUsage)) }
+func (h *keyHeap) Pop() interface{} {
+	old := *h
+	k := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return k
+}
+
+// countingReader count bytes read by decoder
+type countingReader struct {
+	r rdb.ByteReader
+	n uint64
+}
+
+func (r *countingReader) ReadByte() (byte, error) {
+	b, err := r.r.ReadByte()
+	if err == nil {
+		r.n++
+	}
+	return b, err
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += uint64(n)
+	return n, err
+}
@@ -0,0 +1,117 @@
+package memory
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrskom/go-redis-replication/rdb"
+)
+
+func buildRDB(t *testing.T) []byte {
+	buf := &bytes.Buffer{}
+	e := rdb.NewEncoder(buf)
+	e.RDBVersion("0009")
+	e.SelectDB(0)
+	expiry := time.Unix(1700000000, 0)
+	e.Row(&rdb.Row{Key: "user:1", Expiry: &expiry, Value: &rdb.StringValue{Val: rdb.Bytes{Val: []byte("short")}}})
+	e.Row(&rdb.Row{Key: "user:2", Value: &rdb.StringValue{Val: rdb.Bytes{Val: []byte(strings.Repeat("x", 1000))}}})
+	e.SelectDB(1)
+	e.Row(&rdb.Row{Key: "list", Value: &rdb.ListValue{Elements: []rdb.Bytes{{Val: []byte("a")}, {Val: []byte("b")}}}})
+	e.End(nil)
+	require.NoError(t, e.Err())
+	return buf.Bytes()
+}
+
+func TestAnalyze_GivenRows_Report(t *testing.T) {
+	r := require.New(t)
+	data := buildRDB(t)
+	keys := &bytes.Buffer{}
+
+	report, err := Analyze(bytes.NewBuffer(data), WithTopN(2), WithKeysCSV(keys))
+	r.NoError(err)
+
+	r.Equal(uint64(3), report.Total.Keys)
+	r.Equal(uint64(2), report.ByType["string"].Keys)
+	r.Equal(uint64(1), report.ByType["list"].Keys)
+	r.Equal(uint64(1), report.ByEncoding["list"].Keys)
+	r.Equal(uint64(2), report.ByPrefix["user:"].Keys)
+	r.Equal(uint64(1), report.ByPrefix[NoPrefix].Keys)
+	r.Equal(uint64(1007), report.Total.Elements)
+
+	// magic, select db, EOF and checksum aren't part of keys
+	r.Equal(uint64(len(data)-9-2-2-9), report.Total.Serialized)
+
+	r.Len(report.TopKeys, 2)
+	r.Equal("user:2", report.TopKeys[0].Key)
+	r.True(report.TopKeys[0].Memory > report.TopKeys[1].Memory)
+
+	records, err := csv.NewReader(keys).ReadAll()
+	r.NoError(err)
+	r.Len(records, 4)
+	r.Equal(csvHeader, records[0])
+	r.Equal([]string{"0", "user:1", "string", "string", records[1][4], "23", "5", "1700000000000"}, records[1])
+	r.Equal("1", records[3][0])
+	r.Equal("list", records[3][1])
+}
+
+func TestAnalyze_GivenPrefixDepth_Prefixes(t *testing.T) {
+	r := require.New(t)
+	buf := &bytes.Buffer{}
+	e := rdb.NewEncoder(buf)
+	e.Row(&rdb.Row{Key: "a/b/c", Value: &rdb.StringValue{Val: rdb.Bytes{Val: []byte("1")}}})
+	e.Row(&rdb.Row{Key: "a/b", Value: &rdb.StringValue{Val: rdb.Bytes{Val: []byte("1")}}})
+	e.End(nil)
+	r.NoError(e.Err())
+
+	report, err := Analyze(buf, WithPrefix("/", 2))
+	r.NoError(err)
+	r.Equal(uint64(1), report.ByPrefix["a/b/"].Keys)
+	r.Equal(uint64(1), report.ByPrefix[NoPrefix].Keys)
+}
+
+func TestReport_WriteSummary(t *testing.T) {
+	r := require.New(t)
+	data, err := ioutil.ReadFile("../e2e/rdb/rdb")
+	r.NoError(err)
+	report, err := Analyze(bytes.NewBuffer(data))
+	r.NoError(err)
+	r.Len(report.TopKeys, DefaultTopN)
+
+	out := &bytes.Buffer{}
+	r.NoError(report.WriteSummary(out))
+	r.Contains(out.String(), "Keys:        42\n")
+	r.Contains(out.String(), "hash_ziplist")
+	r.Contains(out.String(), "u:")
+}
+
+func TestAnalyze_GivenModuleAuxAndFunction_NotCountedInRows(t *testing.T) {
+	r := require.New(t)
+	build := func(extra bool) []byte {
+		buf := &bytes.Buffer{}
+		e := rdb.NewEncoder(buf)
+		e.RDBVersion("0010")
+		e.SelectDB(0)
+		e.Row(&rdb.Row{Key: "a", Value: &rdb.StringValue{Val: rdb.Bytes{Val: []byte("1")}}})
+		if extra {
+			e.ModuleAux(&rdb.ModuleAux{When: 2, Data: []interface{}{rdb.Bytes{Val: []byte("config")}}})
+			e.Function([]byte("#!lua name=lib\nredis.register_function('f', function() return 1 end)"))
+		}
+		e.Row(&rdb.Row{Key: "b", Value: &rdb.StringValue{Val: rdb.Bytes{Val: []byte("2")}}})
+		e.End(nil)
+		r.NoError(e.Err())
+		return buf.Bytes()
+	}
+
+	expected, err := Analyze(bytes.NewBuffer(build(false)))
+	r.NoError(err)
+	report, err := Analyze(bytes.NewBuffer(build(true)))
+	r.NoError(err)
+	r.Equal(expected.Total, report.Total)
+	r.Equal(expected.TopKeys, report.TopKeys)
+}
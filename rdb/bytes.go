@@ -1,3 +1,33 @@
 package rdb
 
+import (
+	"strconv"
+)
 
+// Bytes is binary safe value of RDB string or element of collection.
+// IsInt is true, when value was encoded as integer, Val contains decimal representation then.
+type Bytes struct {
+	Val   []byte
+	IsInt bool
+}
+
+func NewIntBytes(v int64) Bytes {
+	return Bytes{Val: []byte(strconv.FormatInt(v, 10)), IsInt: true}
+}
+
+func (b Bytes) String() string {
+	return string(b.Val)
+}
+
+// Int return value as int, it's correct only for int encoded values or strings with decimal ints
+func (b Bytes) Int() (int64, error) {
+	return strconv.ParseInt(string(b.Val), 10, 64)
+}
+
+func bytesToStrings(list []Bytes) []string {
+	res := make([]string, 0, len(list))
+	for _, b := range list {
+		res = append(res, b.String())
+	}
+	return res
+}
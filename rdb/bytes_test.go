@@ -0,0 +1,52 @@
+package rdb
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewIntBytes_GivenInt_DecimalWithIntFlag(t *testing.T) {
+	r := require.New(t)
+
+	b := NewIntBytes(-123)
+	r.True(b.IsInt)
+	r.Equal("-123", b.String())
+	v, err := b.Int()
+	r.NoError(err)
+	r.Equal(int64(-123), v)
+}
+
+func TestEncodeString_GivenBytes_SameBytesAfterDecode(t *testing.T) {
+	r := require.New(t)
+	dp := []Bytes{
+		{Val: []byte("123")},
+		{Val: []byte{0x00, 0xFF, 0x0A}},
+		{Val: []byte{}},
+		{Val: bytes.Repeat([]byte("a"), 100)},
+		NewIntBytes(123),
+		NewIntBytes(-32768),
+		NewIntBytes(1 << 30),
+		// int out of int32 is encoded as string
+		{Val: []byte("4294967296")},
+	}
+
+	for k, data := range dp {
+		t.Run(fmt.Sprintf("Data provider #%d", k), func(t *testing.T) {
+			res, err := NewStringDecoder(bytes.NewReader(EncodeString(data))).DecodeBinary()
+			r.NoError(err)
+			r.Equal(data, res)
+		})
+	}
+}
+
+func TestEncodeString_GivenIntBytes_MinimalIntEncoding(t *testing.T) {
+	r := require.New(t)
+
+	r.Equal([]byte{0xC0, 0x7B}, EncodeString(NewIntBytes(123)))
+	r.Equal([]byte{0xC1, 0x39, 0x30}, EncodeString(NewIntBytes(12345)))
+	r.Equal([]byte{0xC2, 0x7A, 0x3C, 0xC3, 0x5C}, EncodeString(NewIntBytes(1556298874)))
+	r.Equal([]byte{0x03, '1', '2', '3'}, EncodeString(Bytes{Val: []byte("123")}))
+}
@@ -6,6 +6,7 @@ import (
 
 type AuxiliaryField struct {
 	K string
+	// V is int64 for int encoded values and string for others
 	V interface{}
 }
 
@@ -18,6 +19,37 @@ type Consumer interface {
 	End(crc []byte)
 }
 
+// ModuleAuxConsumer is optional extension of Consumer, which gets auxiliary data of modules saved out of keys.
+// Without it module aux data is skipped.
+type ModuleAuxConsumer interface {
+	Consumer
+	ModuleAux(aux *ModuleAux)
+}
+
+// FunctionConsumer is optional extension of Consumer, which gets code of function libraries of redis 7.
+// Without it functions are skipped.
+type FunctionConsumer interface {
+	Consumer
+	Function(code []byte)
+}
+
+// ElementConsumer is optional extension of Consumer for big values.
+// Decoder doesn't collect values of rows for it, collections are passed by chunks between BeginRow and EndRow.
+// Strings, streams and modules are passed by one chunk. Row method isn't called for ElementConsumer.
+type ElementConsumer interface {
+	Consumer
+	BeginRow(row *Row)
+	Elements(row *Row, chunk Value)
+	EndRow(row *Row)
+}
+
+// StatsConsumer is optional extension of Consumer, which gets statistics of decoding, e.g. number of skipped keys.
+// Stats is called before End.
+type StatsConsumer interface {
+	Consumer
+	Stats(stats Stats)
+}
+
 type LogConsumer struct {
 	n uint64
 }
@@ -0,0 +1,55 @@
+package rdb
+
+import (
+	"fmt"
+	"hash/crc64"
+)
+
+// crc64JonesPoly is reversed polynomial 0xad93d23594c935a9, which is used by redis
+const crc64JonesPoly = 0x95AC9329AC4BC9B5
+
+var crc64JonesTable = crc64.MakeTable(crc64JonesPoly)
+
+// CRC64 update redis crc64 (Jones polynomial, without inversion) with bytes
+func CRC64(crc uint64, p []byte) uint64 {
+	// std crc64 inverts crc before and after update, so it must be reverted
+	return ^crc64.Update(^crc, crc64JonesTable, p)
+}
+
+type ChecksumMismatchError struct {
+	Expected uint64
+	Actual   uint64
+}
+
+func (e *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("rdb checksum mismatch, expected %016x, actual %016x", e.Expected, e.Actual)
+}
+
+// crcReader calculate crc64 of all read bytes
+type crcReader struct {
+	r   ByteReader
+	crc uint64
+}
+
+func newCRCReader(r ByteReader) *crcReader {
+	return &crcReader{r: r}
+}
+
+func (r *crcReader) ReadByte() (byte, error) {
+	b, err := r.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	r.crc = CRC64(r.crc, []byte{b})
+	return b, nil
+}
+
+func (r *crcReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.crc = CRC64(r.crc, p[:n])
+	return n, err
+}
+
+func (r *crcReader) Sum() uint64 {
+	return r.crc
+}
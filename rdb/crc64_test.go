@@ -0,0 +1,34 @@
+package rdb
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCRC64_GivenCheckString_RedisCheckValue(t *testing.T) {
+	r := require.New(t)
+
+	r.Equal(uint64(0xe9c6d914c4b8d9ca), CRC64(0, []byte("123456789")))
+}
+
+func TestCRC64_GivenBytesByParts_SameAsWhole(t *testing.T) {
+	r := require.New(t)
+	data := []byte("REDIS0009 some bytes of rdb")
+
+	r.Equal(CRC64(0, data), CRC64(CRC64(0, data[:5]), data[5:]))
+}
+
+func TestCRCReader_ReadByteAndRead_CRCOfAllReadBytes(t *testing.T) {
+	r := require.New(t)
+	data := []byte("123456789")
+	cr := newCRCReader(bytes.NewReader(data))
+
+	_, err := cr.ReadByte()
+	r.NoError(err)
+	_, err = io.ReadFull(cr, make([]byte, 8))
+	r.NoError(err)
+	r.Equal(uint64(0xe9c6d914c4b8d9ca), cr.Sum())
+}
@@ -1,18 +1,99 @@
 package rdb
 
 import (
+	"encoding/binary"
 	"errors"
+	"io"
 	"log"
+	"strconv"
+	"time"
+)
+
+const (
+	// checksumMinVersion is first rdb version with crc64 after EOF
+	checksumMinVersion = 5
+
+	DefaultChunkSize = 1024
 )
 
 type Decoder struct {
 	r                  ByteReader
+	crc                *crcReader
 	c                  Consumer
+	chunkSize          int
+	filter             RowFilter
+	expiredKeys        ExpiredKeys
+	now                func() time.Time
+	stats              Stats
+	db                 uint32
 	decodeDbInProgress bool
 }
 
-func NewDecoder(r ByteReader, c Consumer) *Decoder {
-	return &Decoder{r: r, c: c}
+// ExpiredKeys is policy for keys, which are expired by clock of decoder.
+// Key is expired when expiry is before current time like in redis.
+type ExpiredKeys int
+
+const (
+	// ExpiredKeysKeep pass expired keys to consumer like others
+	ExpiredKeysKeep ExpiredKeys = iota
+	// ExpiredKeysDrop skip expired keys without decoding of values
+	ExpiredKeysDrop
+	// ExpiredKeysFlag pass expired keys to consumer with Row.Expired
+	ExpiredKeysFlag
+)
+
+// Stats is statistics of decoding, it's passed to StatsConsumer before End
+type Stats struct {
+	// ExpiredSkipped is number of keys dropped by ExpiredKeysDrop
+	ExpiredSkipped uint64
+	// ExpiredFlagged is number of keys flagged by ExpiredKeysFlag
+	ExpiredFlagged uint64
+	// Filtered is number of keys, which aren't matched by filter
+	Filtered uint64
+}
+
+type DecoderOption func(d *Decoder)
+
+// WithChunkSize set max number of elements in one chunk for ElementConsumer, 0 means one chunk for whole value
+func WithChunkSize(size int) DecoderOption {
+	return func(d *Decoder) {
+		d.chunkSize = size
+	}
+}
+
+// WithFilter set filter of rows, values of not matched rows are skipped without decoding
+func WithFilter(f RowFilter) DecoderOption {
+	return func(d *Decoder) {
+		d.filter = f
+	}
+}
+
+// WithExpiredKeys set policy for keys, which are expired by clock of decoder
+func WithExpiredKeys(policy ExpiredKeys) DecoderOption {
+	return func(d *Decoder) {
+		d.expiredKeys = policy
+	}
+}
+
+// WithClock set clock for check of expiry, time.Now is used by default
+func WithClock(now func() time.Time) DecoderOption {
+	return func(d *Decoder) {
+		d.now = now
+	}
+}
+
+func NewDecoder(r ByteReader, c Consumer, opts ...DecoderOption) *Decoder {
+	crc := newCRCReader(r)
+	d := &Decoder{r: crc, crc: crc, c: c, chunkSize: DefaultChunkSize, now: time.Now}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Stats return statistics of decoding
+func (d *Decoder) Stats() Stats {
+	return d.stats
 }
 
 func (d *Decoder) Decode() error {
@@ -20,6 +101,10 @@ func (d *Decoder) Decode() error {
 	if err != nil {
 		return err
 	}
+	version, err := strconv.Atoi(rdbVersion)
+	if err != nil {
+		return errors.New("unexpected format of rdb version")
+	}
 	d.c.RDBVersion(rdbVersion)
 	for {
 		b, err := d.r.ReadByte()
@@ -28,18 +113,15 @@ func (d *Decoder) Decode() error {
 		}
 		switch b {
 		case OpCodeAUX:
-			aux := AuxiliaryField{}
-			key, err := d.readString()
+			key, err := NewStringDecoder(d.r).Decode()
 			if err != nil {
 				return err
 			}
-			aux.K = key
-			val, err := d.readVal()
+			val, err := NewStringDecoder(d.r).DecodeBinary()
 			if err != nil {
 				return err
 			}
-			aux.V = val
-			d.c.AuxiliaryField(aux)
+			d.c.AuxiliaryField(AuxiliaryField{K: key, V: auxValue(val)})
 		case OpCodeRESIZEDB:
 			dbHTSize, err := DecodeLength(d.r)
 			if err != nil {
@@ -49,80 +131,107 @@ func (d *Decoder) Decode() error {
 			if err != nil {
 				return err
 			}
+			// sizes are only hints, rows will be read by loop
 			d.c.ResizeDB(dbHTSize.GetLength(), expiryHTSize.GetLength())
-			if err := d.readDB(dbHTSize.GetLength()); err != nil {
+		case OpCodeEXPIRETIMEMS, OpCodeEXPIRETIME, OpCodeIDLE, OpCodeFREQ:
+			// expiry, idle and freq are the prefix of the next row
+			if err := d.readRow(b); err != nil {
+				return err
+			}
+		case OpCodeMODULEAUX:
+			aux, err := NewModuleDecoder(d.r).DecodeAux()
+			if err != nil {
 				return err
 			}
-		case OpCodeEXPIRETIMEMS:
-			panic("not implemented")
-		case OpCodeEXPIRETIME:
-			panic("not implemented")
+			if mc, ok := d.c.(ModuleAuxConsumer); ok {
+				mc.ModuleAux(aux)
+			}
+		case OpCodeFUNCTION2:
+			code, err := NewStringDecoder(d.r).DecodeToBytes()
+			if err != nil {
+				return err
+			}
+			if fc, ok := d.c.(FunctionConsumer); ok {
+				fc.Function(code)
+			}
 		case OpCodeSELECTDB:
 			db, err := DecodeLength(d.r)
 			if err != nil {
 				return err
 			}
-			d.c.SelectDB(db.GetLength())
+			d.db = db.GetLength()
+			d.c.SelectDB(d.db)
 		case OpCodeEOF:
-			crc := make([]byte, 8)
-			_, err := d.r.Read(crc)
-			if err != nil {
+			if version < checksumMinVersion {
+				d.end(nil)
 				return nil
 			}
-			d.c.End(crc)
+			// checksum includes all bytes before itself, EOF op code too
+			actual := d.crc.Sum()
+			crc := make([]byte, 8)
+			if _, err := io.ReadFull(d.r, crc); err != nil {
+				return err
+			}
+			// zero checksum means that checksum is disabled on server
+			expected := binary.LittleEndian.Uint64(crc)
+			if expected != 0 && expected != actual {
+				return &ChecksumMismatchError{Expected: expected, Actual: actual}
+			}
+			d.end(crc)
 			return nil
 		default:
-			log.Printf("%#x", b)
-			return errors.New("unexpected op code")
+			if !IsRowStart(b) {
+				log.Printf("%#x", b)
+				return errors.New("unexpected op code")
+			}
+			if err := d.readRow(b); err != nil {
+				return err
+			}
 		}
 	}
 }
 
-func (d *Decoder) readVal() (interface{}, error) {
-	length, err := DecodeLength(d.r)
-	if err != nil {
-		return "", err
-	}
-	switch length.GetType() {
-	case LengthType6Bits, LengthType4Bytes:
-		return DecodeLengthPrefixedString(d.r, length.GetLength())
-	case LengthTypeEncodedStringInt:
-		return DecodeEncodedStringInt(d.r, length.GetLength())
+// auxValue return int64 for int encoded value and string for others
+func auxValue(val Bytes) interface{} {
+	if val.IsInt {
+		if i, err := val.Int(); err == nil {
+			return i
+		}
 	}
-	return 0, errors.New("unexpected type of string")
+	return val.String()
 }
 
-func (d *Decoder) readString() (string, error) {
-	length, err := DecodeLength(d.r)
+func (d *Decoder) readRow(firstByte byte) error {
+	rd := NewChunkedRowDecoder(d.r, d.chunkSize)
+	row, err := rd.decodeHeader(firstByte)
 	if err != nil {
-		return "", err
+		return err
 	}
-	if length.IsEncodedString() {
-		return "", errors.New("unexpected length type while read string")
+	if row.Expiry != nil && d.expiredKeys != ExpiredKeysKeep && d.now().After(*row.Expiry) {
+		if d.expiredKeys == ExpiredKeysDrop {
+			d.stats.ExpiredSkipped++
+			return rd.skipValue(row.Type)
+		}
+		row.Expired = true
+		d.stats.ExpiredFlagged++
 	}
-	return DecodeLengthPrefixedString(d.r, length.GetLength())
-}
-
-func (d *Decoder) readInt() (uint32, error) {
-	length, err := DecodeLength(d.r)
-	if err != nil {
-		return 0, err
+	if d.filter != nil && !d.filter.Match(d.db, row, d.now) {
+		d.stats.Filtered++
+		return rd.skipValue(row.Type)
 	}
-	if length.GetType() != LengthTypeEncodedStringInt {
-		return 0, errors.New("unexpected length type while expected encoded int")
+	if ec, ok := d.c.(ElementConsumer); ok {
+		return rd.decodeRowElements(row, ec)
 	}
-	return DecodeEncodedStringInt(d.r, length.GetLength())
+	if err := rd.decodeRowValue(row); err != nil {
+		return err
+	}
+	d.c.Row(row)
+	return nil
 }
 
-func (d *Decoder) readDB(numKey uint32) error {
-	for numKey > 0 {
-		numKey--
-		rd := &RowDecoder{d.r}
-		row, err := rd.Decode()
-		if err != nil {
-			return err
-		}
-		d.c.Row(row)
+func (d *Decoder) end(crc []byte) {
+	if sc, ok := d.c.(StatsConsumer); ok {
+		sc.Stats(d.stats)
 	}
-	return nil
+	d.c.End(crc)
 }
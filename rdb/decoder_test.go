@@ -2,17 +2,394 @@ package rdb
 
 import (
 	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
 	"io/ioutil"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
 
+type testConsumer struct {
+	version string
+	aux     []AuxiliaryField
+	dbs     []uint32
+	rows    []*Row
+	crc     []byte
+}
+
+func (c *testConsumer) RDBVersion(version string) {
+	c.version = version
+}
+
+func (c *testConsumer) AuxiliaryField(field AuxiliaryField) {
+	c.aux = append(c.aux, field)
+}
+
+func (c *testConsumer) ResizeDB(dbHashtableSize uint32, expiryHashtableSize uint32) {
+}
+
+func (c *testConsumer) SelectDB(db uint32) {
+	c.dbs = append(c.dbs, db)
+}
+
+func (c *testConsumer) Row(row *Row) {
+	c.rows = append(c.rows, row)
+}
+
+func (c *testConsumer) End(crc []byte) {
+	c.crc = crc
+}
+
 func TestDecoder_Decode(t *testing.T) {
 	r := require.New(t)
-	data, err := ioutil.ReadFile("/Users/andrskom/go/src/github.com/andrskom/go-redis-replication/rdb/rdb")
+	data, err := ioutil.ReadFile("../e2e/rdb/rdb")
 	r.NoError(err)
 	dec := NewDecoder(bytes.NewBuffer(data), &LogConsumer{})
 	err = dec.Decode()
 	r.NoError(err)
 }
+
+func TestDecoder_Decode_GivenTopLevelRowsWithoutResizeDB_Rows(t *testing.T) {
+	r := require.New(t)
+	data := []byte("REDIS0009")
+	data = append(data,
+		OpCodeSELECTDB, 0x01,
+		OpCodeEXPIRETIMEMS, 0xE8, 0x03, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // 1000 ms
+		byte(ValueTypeString), 0x01, 'a', 0x01, '1',
+		OpCodeEXPIRETIME, 0x02, 0x00, 0x00, 0x00, // 2 s
+		byte(ValueTypeString), 0x01, 'b', 0x01, '2',
+		byte(ValueTypeString), 0x01, 'c', 0x01, '3',
+		OpCodeEOF, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	)
+	c := &testConsumer{}
+
+	r.NoError(NewDecoder(bytes.NewBuffer(data), c).Decode())
+	r.Equal("0009", c.version)
+	r.Equal([]uint32{1}, c.dbs)
+	r.Len(c.rows, 3)
+	r.Equal("a", c.rows[0].Key)
+	r.Equal(&StringValue{Val: Bytes{Val: []byte("1")}}, c.rows[0].Value)
+	r.NotNil(c.rows[0].Expiry)
+	r.True(time.Unix(1, 0).Equal(*c.rows[0].Expiry))
+	r.Equal("b", c.rows[1].Key)
+	r.Equal(&StringValue{Val: Bytes{Val: []byte("2")}}, c.rows[1].Value)
+	r.NotNil(c.rows[1].Expiry)
+	r.True(time.Unix(2, 0).Equal(*c.rows[1].Expiry))
+	r.Equal("c", c.rows[2].Key)
+	r.Equal(&StringValue{Val: Bytes{Val: []byte("3")}}, c.rows[2].Value)
+	r.Nil(c.rows[2].Expiry)
+}
+
+func TestDecoder_Decode_GivenUnexpectedOpCode_Err(t *testing.T) {
+	r := require.New(t)
+	data := append([]byte("REDIS0009"), 0xF0)
+
+	err := NewDecoder(bytes.NewBuffer(data), &testConsumer{}).Decode()
+	r.Error(err)
+	r.Equal("unexpected op code", err.Error())
+}
+
+type testExtraConsumer struct {
+	testConsumer
+	moduleAux []*ModuleAux
+	functions [][]byte
+}
+
+func (c *testExtraConsumer) ModuleAux(aux *ModuleAux) {
+	c.moduleAux = append(c.moduleAux, aux)
+}
+
+func (c *testExtraConsumer) Function(code []byte) {
+	c.functions = append(c.functions, code)
+}
+
+func TestDecoder_Decode_GivenIdleAndFreq_Rows(t *testing.T) {
+	r := require.New(t)
+	type testData struct {
+		prefix       []byte
+		expectedIdle *uint64
+		expectedFreq *uint8
+		hasExpiry    bool
+	}
+	idle := uint64(300)
+	freq := uint8(5)
+	dp := []testData{
+		{prefix: []byte{OpCodeIDLE, 0x41, 0x2C}, expectedIdle: &idle},
+		{prefix: []byte{OpCodeFREQ, 0x05}, expectedFreq: &freq},
+		{
+			prefix:       []byte{OpCodeEXPIRETIME, 0x02, 0x00, 0x00, 0x00, OpCodeIDLE, 0x41, 0x2C},
+			expectedIdle: &idle,
+			hasExpiry:    true,
+		},
+		{
+			prefix:       []byte{OpCodeEXPIRETIMEMS, 0xE8, 0x03, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, OpCodeFREQ, 0x05},
+			expectedFreq: &freq,
+			hasExpiry:    true,
+		},
+	}
+
+	for k, data := range dp {
+		t.Run(fmt.Sprintf("Data provider #%d", k), func(t *testing.T) {
+			rdb := append([]byte("REDIS0011"), OpCodeSELECTDB, 0x00)
+			rdb = append(rdb, data.prefix...)
+			rdb = append(rdb, byte(ValueTypeString), 0x01, 'k', 0x01, 'v')
+			rdb = append(rdb, OpCodeEOF, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00)
+			c := &testConsumer{}
+
+			r.NoError(NewDecoder(bytes.NewReader(rdb), c).Decode())
+			r.Len(c.rows, 1)
+			r.Equal("k", c.rows[0].Key)
+			r.Equal(&StringValue{Val: Bytes{Val: []byte("v")}}, c.rows[0].Value)
+			r.Equal(data.expectedIdle, c.rows[0].Idle)
+			r.Equal(data.expectedFreq, c.rows[0].Freq)
+			r.Equal(data.hasExpiry, c.rows[0].Expiry != nil)
+		})
+	}
+}
+
+func buildFunctionRDB() []byte {
+	return append([]byte("REDIS0010"),
+		OpCodeFUNCTION2, 0x04, 'c', 'o', 'd', 'e',
+		OpCodeSELECTDB, 0x00,
+		byte(ValueTypeString), 0x01, 'k', 0x01, 'v',
+		OpCodeEOF, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	)
+}
+
+func TestDecoder_Decode_GivenFunction_Passed(t *testing.T) {
+	r := require.New(t)
+	c := &testExtraConsumer{}
+
+	r.NoError(NewDecoder(bytes.NewReader(buildFunctionRDB()), c).Decode())
+	r.Equal([][]byte{[]byte("code")}, c.functions)
+	r.Len(c.rows, 1)
+	r.Equal("k", c.rows[0].Key)
+}
+
+func TestDecoder_Decode_GivenFunctionWithoutConsumer_Skipped(t *testing.T) {
+	r := require.New(t)
+	c := &testConsumer{}
+
+	r.NoError(NewDecoder(bytes.NewReader(buildFunctionRDB()), c).Decode())
+	r.Len(c.rows, 1)
+	r.Equal("k", c.rows[0].Key)
+}
+
+func TestDecoder_Decode_GivenCorruptedFile_ChecksumMismatchErr(t *testing.T) {
+	r := require.New(t)
+	data, err := ioutil.ReadFile("../e2e/rdb/rdb")
+	r.NoError(err)
+	// change one byte of aux value "redis-ver"
+	data[0x16]++
+
+	err = NewDecoder(bytes.NewBuffer(data), &testConsumer{}).Decode()
+	r.Error(err)
+	r.IsType(&ChecksumMismatchError{}, err)
+	r.Equal(uint64(0x8ee92071a89e6d32), err.(*ChecksumMismatchError).Expected)
+}
+
+func TestDecoder_Decode_GivenCorrectChecksum_End(t *testing.T) {
+	r := require.New(t)
+	data := append([]byte("REDIS0009"), OpCodeSELECTDB, 0x00, OpCodeEOF)
+	crc := make([]byte, 8)
+	binary.LittleEndian.PutUint64(crc, CRC64(0, data))
+	data = append(data, crc...)
+	c := &testConsumer{}
+
+	r.NoError(NewDecoder(bytes.NewBuffer(data), c).Decode())
+	r.Equal(crc, c.crc)
+}
+
+func TestDecoder_Decode_GivenShortChecksum_Err(t *testing.T) {
+	r := require.New(t)
+	data := append([]byte("REDIS0009"), OpCodeEOF, 0x01, 0x02)
+
+	err := NewDecoder(bytes.NewBuffer(data), &testConsumer{}).Decode()
+	r.Error(err)
+	r.Equal(io.ErrUnexpectedEOF, err)
+}
+
+func TestDecoder_Decode_GivenVersionWithoutChecksum_End(t *testing.T) {
+	r := require.New(t)
+	data := append([]byte("REDIS0004"), OpCodeSELECTDB, 0x00, OpCodeEOF)
+	c := &testConsumer{}
+
+	r.NoError(NewDecoder(bytes.NewBuffer(data), c).Decode())
+	r.Nil(c.crc)
+}
+
+func buildModuleAuxRDB() []byte {
+	data := append([]byte("REDIS0010"), OpCodeMODULEAUX)
+	data = append(data, EncodeLength(moduleTypeID("scripting", 1))...)
+	return append(data,
+		moduleOpCodeUInt, 0x02, // when
+		moduleOpCodeString, 0x02, 'o', 'k',
+		moduleOpCodeEOF,
+		OpCodeSELECTDB, 0x00,
+		byte(ValueTypeString), 0x01, 'k', 0x01, 'v',
+		OpCodeEOF, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	)
+}
+
+func TestDecoder_Decode_GivenModuleAux_Passed(t *testing.T) {
+	r := require.New(t)
+	c := &testExtraConsumer{}
+
+	r.NoError(NewDecoder(bytes.NewReader(buildModuleAuxRDB()), c).Decode())
+	r.Equal([]*ModuleAux{{
+		ID:     moduleTypeID("scripting", 1),
+		Name:   "scripting",
+		EncVer: 1,
+		When:   2,
+		Data:   []interface{}{Bytes{Val: []byte("ok")}},
+	}}, c.moduleAux)
+	r.Len(c.rows, 1)
+	r.Equal("k", c.rows[0].Key)
+}
+
+func TestDecoder_Decode_GivenModuleAuxWithoutConsumer_Skipped(t *testing.T) {
+	r := require.New(t)
+	c := &testConsumer{}
+
+	r.NoError(NewDecoder(bytes.NewReader(buildModuleAuxRDB()), c).Decode())
+	r.Len(c.rows, 1)
+	r.Equal("k", c.rows[0].Key)
+}
+
+func TestDecoder_Decode_GivenModuleAuxWithoutWhen_Err(t *testing.T) {
+	r := require.New(t)
+	data := append([]byte("REDIS0010"), OpCodeMODULEAUX)
+	data = append(data, EncodeLength(moduleTypeID("scripting", 1))...)
+	data = append(data, moduleOpCodeEOF)
+
+	r.Error(NewDecoder(bytes.NewReader(data), &testExtraConsumer{}).Decode())
+}
+
+type testStatsConsumer struct {
+	testConsumer
+	stats      *Stats
+	statsAtEnd bool
+}
+
+func (c *testStatsConsumer) Stats(stats Stats) {
+	c.stats = &stats
+	c.statsAtEnd = c.crc == nil
+}
+
+func buildExpiryRDB(t *testing.T) []byte {
+	buf := &bytes.Buffer{}
+	e := NewEncoder(buf)
+	past := time.Unix(900, 0)
+	now := time.Unix(1000, 0)
+	future := time.Unix(1100, 0)
+	e.Row(&Row{Key: "past", Expiry: &past, Value: &ListValue{Elements: []Bytes{{Val: []byte("a")}}}})
+	e.Row(&Row{Key: "now", Expiry: &now, Value: &StringValue{Val: Bytes{Val: []byte("b")}}})
+	e.Row(&Row{Key: "future", Expiry: &future, Value: &StringValue{Val: Bytes{Val: []byte("c")}}})
+	e.Row(&Row{Key: "persistent", Value: &StringValue{Val: Bytes{Val: []byte("d")}}})
+	e.End(nil)
+	require.NoError(t, e.Err())
+	return buf.Bytes()
+}
+
+func TestDecoder_Decode_GivenExpiredKeysPolicy_Rows(t *testing.T) {
+	r := require.New(t)
+	type testData struct {
+		opts          []DecoderOption
+		expectedKeys  []string
+		expired       []bool
+		expectedStats Stats
+	}
+	clock := func() time.Time {
+		return time.Unix(1000, 0)
+	}
+	dp := []testData{
+		// keep by default
+		{
+			opts:         []DecoderOption{WithClock(clock)},
+			expectedKeys: []string{"past", "now", "future", "persistent"},
+			expired:      []bool{false, false, false, false},
+		},
+		// drop
+		{
+			opts:          []DecoderOption{WithClock(clock), WithExpiredKeys(ExpiredKeysDrop)},
+			expectedKeys:  []string{"now", "future", "persistent"},
+			expired:       []bool{false, false, false},
+			expectedStats: Stats{ExpiredSkipped: 1},
+		},
+		// flag
+		{
+			opts:          []DecoderOption{WithClock(clock), WithExpiredKeys(ExpiredKeysFlag)},
+			expectedKeys:  []string{"past", "now", "future", "persistent"},
+			expired:       []bool{true, false, false, false},
+			expectedStats: Stats{ExpiredFlagged: 1},
+		},
+		// drop and filter
+		{
+			opts: []DecoderOption{
+				WithClock(clock),
+				WithExpiredKeys(ExpiredKeysDrop),
+				WithFilter(&KeyFilter{Globs: []string{"f*"}}),
+			},
+			expectedKeys:  []string{"future"},
+			expired:       []bool{false},
+			expectedStats: Stats{ExpiredSkipped: 1, Filtered: 2},
+		},
+	}
+
+	for k, data := range dp {
+		t.Run(fmt.Sprintf("Data provider #%d", k), func(t *testing.T) {
+			c := &testStatsConsumer{}
+			d := NewDecoder(bytes.NewBuffer(buildExpiryRDB(t)), c, data.opts...)
+			r.NoError(d.Decode())
+
+			keys := make([]string, 0, len(c.rows))
+			expired := make([]bool, 0, len(c.rows))
+			for _, row := range c.rows {
+				keys = append(keys, row.Key)
+				expired = append(expired, row.Expired)
+			}
+			r.Equal(data.expectedKeys, keys)
+			r.Equal(data.expired, expired)
+			r.Equal(data.expectedStats, d.Stats())
+			r.NotNil(c.stats)
+			r.Equal(data.expectedStats, *c.stats)
+			r.True(c.statsAtEnd)
+		})
+	}
+}
+
+func TestDecoder_Decode_GivenAuxFields_StringsAndInts(t *testing.T) {
+	r := require.New(t)
+	data, err := ioutil.ReadFile("../e2e/rdb/rdb")
+	r.NoError(err)
+	c := &testConsumer{}
+
+	r.NoError(NewDecoder(bytes.NewBuffer(data), c).Decode())
+	aux := make(map[string]interface{}, len(c.aux))
+	for _, field := range c.aux {
+		aux[field.K] = field.V
+	}
+	r.Equal("5.0.4", aux["redis-ver"])
+	r.Equal(int64(64), aux["redis-bits"])
+	r.Equal(int64(0), aux["aof-preamble"])
+	r.Equal("964161cf0720932cf4987598ec40d7cc2dc3ecfe", aux["repl-id"])
+}
+
+func TestDecoder_Decode_GivenLongAndCompressedAuxValue_Value(t *testing.T) {
+	r := require.New(t)
+	long := strings.Repeat("value ", 20)
+	buf := &bytes.Buffer{}
+	e := NewEncoder(buf, WithCompression())
+	e.AuxiliaryField(AuxiliaryField{K: "long", V: long})
+	e.AuxiliaryField(AuxiliaryField{K: "int", V: int64(-100000)})
+	e.End(nil)
+	r.NoError(e.Err())
+	c := &testConsumer{}
+
+	r.NoError(NewDecoder(buf, c).Decode())
+	r.Equal([]AuxiliaryField{{K: "long", V: long}, {K: "int", V: int64(-100000)}}, c.aux)
+}
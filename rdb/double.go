@@ -0,0 +1,65 @@
+package rdb
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+)
+
+// DecodeDouble decode double saved as string, it's used by sorted set scores in ZSET type
+// Docs https://rdb.fnordig.de/file_format.html#sorted-set-encoding
+func DecodeDouble(r ByteReader) (float64, error) {
+	l, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	switch l {
+	case 253:
+		return math.NaN(), nil
+	case 254:
+		return math.Inf(1), nil
+	case 255:
+		return math.Inf(-1), nil
+	}
+	bytes := make([]byte, l)
+	if _, err := io.ReadFull(r, bytes); err != nil {
+		return 0, err
+	}
+	val, err := strconv.ParseFloat(string(bytes), 64)
+	if err != nil {
+		return 0, errors.New("unexpected format of double")
+	}
+	return val, nil
+}
+
+// DecodeBinaryDouble decode 8 bytes little endian IEEE 754 double, it's used by ZSET_2 type
+func DecodeBinaryDouble(r ByteReader) (float64, error) {
+	bytes := make([]byte, 8)
+	if _, err := io.ReadFull(r, bytes); err != nil {
+		return 0, err
+	}
+	return math.Float64frombits(binary.LittleEndian.Uint64(bytes)), nil
+}
+
+// EncodeDouble encode double as string for ZSET type
+func EncodeDouble(val float64) []byte {
+	switch {
+	case math.IsNaN(val):
+		return []byte{253}
+	case math.IsInf(val, 1):
+		return []byte{254}
+	case math.IsInf(val, -1):
+		return []byte{255}
+	}
+	s := strconv.FormatFloat(val, 'g', 17, 64)
+	return append([]byte{byte(len(s))}, s...)
+}
+
+// EncodeBinaryDouble encode double as 8 bytes little endian for ZSET_2 type
+func EncodeBinaryDouble(val float64) []byte {
+	res := make([]byte, 8)
+	binary.LittleEndian.PutUint64(res, math.Float64bits(val))
+	return res
+}
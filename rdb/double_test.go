@@ -0,0 +1,65 @@
+package rdb
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeDouble_GivenStringDouble_Double(t *testing.T) {
+	r := require.New(t)
+	type testData struct {
+		data        []byte
+		expectedRes float64
+	}
+	dp := []testData{
+		{data: []byte{0x01, '1'}, expectedRes: 1},
+		{data: []byte{0x04, '-', '1', '.', '5'}, expectedRes: -1.5},
+		{data: []byte{0xFE}, expectedRes: math.Inf(1)},
+		{data: []byte{0xFF}, expectedRes: math.Inf(-1)},
+	}
+
+	for k, data := range dp {
+		t.Run(fmt.Sprintf("Data provider #%d", k), func(t *testing.T) {
+			val, err := DecodeDouble(bytes.NewReader(data.data))
+			r.NoError(err)
+			r.Equal(data.expectedRes, val)
+		})
+	}
+}
+
+func TestDecodeDouble_GivenNaNLength_NaN(t *testing.T) {
+	r := require.New(t)
+
+	val, err := DecodeDouble(bytes.NewReader([]byte{0xFD}))
+	r.NoError(err)
+	r.True(math.IsNaN(val))
+}
+
+func TestDecodeDouble_GivenBadFormat_Err(t *testing.T) {
+	r := require.New(t)
+
+	_, err := DecodeDouble(bytes.NewReader([]byte{0x02, 'a', 'b'}))
+	r.Error(err)
+	r.Equal("unexpected format of double", err.Error())
+}
+
+func TestDecodeBinaryDouble_Given8Bytes_Double(t *testing.T) {
+	r := require.New(t)
+
+	val, err := DecodeBinaryDouble(bytes.NewReader([]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xF8, 0x3F}))
+	r.NoError(err)
+	r.Equal(1.5, val)
+}
+
+func TestDecodeBinaryDouble_GivenLessThan8Bytes_Err(t *testing.T) {
+	r := require.New(t)
+
+	_, err := DecodeBinaryDouble(bytes.NewReader([]byte{0x00, 0x00}))
+	r.Error(err)
+	r.Equal(io.ErrUnexpectedEOF, err)
+}
@@ -0,0 +1,545 @@
+package rdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	lzf "github.com/zhuyie/golzf"
+)
+
+const (
+	// DefaultEncoderVersion is used when RDBVersion isn't called before other events
+	DefaultEncoderVersion = "0009"
+
+	// first rdb versions with new types of values, older types are used for older versions
+	zset2MinVersion         = 8
+	listpackMinVersion      = 10
+	setListpackMinVersion   = 11
+	streamListpacks2Version = 10
+	streamListpacks3Version = 11
+	// IDLE and FREQ of keys are saved since rdb version 9
+	idleFreqMinVersion = 9
+
+	// redis doesn't compress short strings
+	compressMinLen = 20
+
+	// limits of compact encodings, they are equal to default limits of redis config
+	compactIntsetMaxEntries   = 512
+	compactListpackMaxEntries = 128
+	compactListpackMaxValue   = 64
+	compactQuicklistNodeSize  = 128
+)
+
+// Encoder write RDB from the same events which are passed to Consumer, so it can be used as Consumer of Decoder.
+// Write errors can't be returned by Consumer methods, first error is saved and returned by Err,
+// all events after error are ignored.
+type Encoder struct {
+	w             io.Writer
+	crc           uint64
+	version       int
+	headerWritten bool
+	compress      bool
+	compact       bool
+	err           error
+}
+
+type EncoderOption func(e *Encoder)
+
+// WithCompression compress long strings by LZF like redis with rdbcompression yes
+func WithCompression() EncoderOption {
+	return func(e *Encoder) {
+		e.compress = true
+	}
+}
+
+// WithCompactEncodings write small values in compact encodings (intset, listpack, quicklist)
+// if they are supported by rdb version, otherwise values are written in plain encodings
+func WithCompactEncodings() EncoderOption {
+	return func(e *Encoder) {
+		e.compact = true
+	}
+}
+
+func NewEncoder(w io.Writer, opts ...EncoderOption) *Encoder {
+	e := &Encoder{w: w}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Err return first error of writing
+func (e *Encoder) Err() error {
+	return e.err
+}
+
+func (e *Encoder) RDBVersion(version string) {
+	if e.headerWritten {
+		e.setErr(errors.New("rdb header is already written"))
+		return
+	}
+	v, err := strconv.Atoi(version)
+	if err != nil || len(version) != 4 {
+		e.setErr(fmt.Errorf("unexpected format of rdb version %q", version))
+		return
+	}
+	e.version = v
+	e.headerWritten = true
+	e.write([]byte("REDIS" + version))
+}
+
+func (e *Encoder) AuxiliaryField(field AuxiliaryField) {
+	val, err := auxValueBytes(field.V)
+	if err != nil {
+		e.setErr(err)
+		return
+	}
+	buf := []byte{OpCodeAUX}
+	buf = append(buf, e.encodeString(Bytes{Val: []byte(field.K)})...)
+	buf = append(buf, e.encodeString(val)...)
+	e.writeEvent(buf)
+}
+
+func (e *Encoder) ResizeDB(dbHashtableSize uint32, expiryHashtableSize uint32) {
+	buf := []byte{OpCodeRESIZEDB}
+	buf = append(buf, EncodeLength(uint64(dbHashtableSize))...)
+	buf = append(buf, EncodeLength(uint64(expiryHashtableSize))...)
+	e.writeEvent(buf)
+}
+
+func (e *Encoder) SelectDB(db uint32) {
+	e.writeEvent(append([]byte{OpCodeSELECTDB}, EncodeLength(uint64(db))...))
+}
+
+func (e *Encoder) Row(row *Row) {
+	buf, err := e.encodeRow(row)
+	if err != nil {
+		e.setErr(err)
+		return
+	}
+	e.writeEvent(buf)
+}
+
+// ModuleAux write aux data of module, Encoder is ModuleAuxConsumer
+func (e *Encoder) ModuleAux(aux *ModuleAux) {
+	data := append([]interface{}{aux.When}, aux.Data...)
+	_, val, err := e.encodeModule(&ModuleValue{ID: aux.ID, Data: data})
+	if err != nil {
+		e.setErr(err)
+		return
+	}
+	e.writeEvent(append([]byte{OpCodeMODULEAUX}, val...))
+}
+
+// Function write code of function library, Encoder is FunctionConsumer
+func (e *Encoder) Function(code []byte) {
+	e.writeEvent(append([]byte{OpCodeFUNCTION2}, e.encodeString(Bytes{Val: code})...))
+}
+
+// End write EOF and checksum of written bytes, passed crc is ignored because it's checksum of source rdb
+func (e *Encoder) End(crc []byte) {
+	e.writeEvent([]byte{OpCodeEOF})
+	if e.err != nil || e.version < checksumMinVersion {
+		return
+	}
+	sum := make([]byte, 8)
+	binary.LittleEndian.PutUint64(sum, e.crc)
+	e.write(sum)
+}
+
+func (e *Encoder) setErr(err error) {
+	if e.err == nil {
+		e.err = err
+	}
+}
+
+// writeEvent write header with default version if it wasn't written
+func (e *Encoder) writeEvent(p []byte) {
+	if !e.headerWritten {
+		e.RDBVersion(DefaultEncoderVersion)
+	}
+	e.write(p)
+}
+
+func (e *Encoder) write(p []byte) {
+	if e.err != nil {
+		return
+	}
+	if _, err := e.w.Write(p); err != nil {
+		e.err = err
+		return
+	}
+	e.crc = CRC64(e.crc, p)
+}
+
+// auxValueBytes convert value of aux field to string, ints are saved as encoded ints
+func auxValueBytes(v interface{}) (Bytes, error) {
+	switch val := v.(type) {
+	case string:
+		return Bytes{Val: []byte(val)}, nil
+	case []byte:
+		return Bytes{Val: val}, nil
+	case Bytes:
+		return val, nil
+	case int:
+		return NewIntBytes(int64(val)), nil
+	case int32:
+		return NewIntBytes(int64(val)), nil
+	case int64:
+		return NewIntBytes(val), nil
+	case uint32:
+		return NewIntBytes(int64(val)), nil
+	}
+	return Bytes{}, fmt.Errorf("unexpected type %T of aux value", v)
+}
+
+// encodeString encode string with int encoding or LZF compression if it's possible
+func (e *Encoder) encodeString(b Bytes) []byte {
+	if e.compress && !b.IsInt && len(b.Val) > compressMinLen {
+		// compressed string must be shorter at least by 4 bytes like in redis
+		out := make([]byte, len(b.Val)-4)
+		if n, err := lzf.Compress(b.Val, out); err == nil && n > 0 {
+			res := []byte{0xC3}
+			res = append(res, EncodeLength(uint64(n))...)
+			res = append(res, EncodeLength(uint64(len(b.Val)))...)
+			return append(res, out[:n]...)
+		}
+	}
+	return EncodeString(b)
+}
+
+func (e *Encoder) encodeRow(row *Row) ([]byte, error) {
+	if row.Value == nil {
+		return nil, errors.New("row without value")
+	}
+	t, val, err := e.encodeValue(row.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 0, len(val)+len(row.RawKey.Val)+16)
+	if row.Expiry != nil {
+		buf = append(buf, OpCodeEXPIRETIMEMS)
+		buf = append(buf, encodeMillisecondTime(*row.Expiry)...)
+	}
+	if e.version >= idleFreqMinVersion {
+		if row.Idle != nil {
+			buf = append(buf, OpCodeIDLE)
+			buf = append(buf, EncodeLength(*row.Idle)...)
+		}
+		if row.Freq != nil {
+			buf = append(buf, OpCodeFREQ, *row.Freq)
+		}
+	}
+	buf = append(buf, byte(t))
+	key := row.RawKey
+	if key.Val == nil {
+		key = Bytes{Val: []byte(row.Key)}
+	}
+	buf = append(buf, e.encodeString(key)...)
+	return append(buf, val...), nil
+}
+
+// encodeValue choose type of value by rdb version and options and return encoded value
+func (e *Encoder) encodeValue(v Value) (ValueType, []byte, error) {
+	switch val := v.(type) {
+	case *StringValue:
+		return ValueTypeString, e.encodeString(val.Val), nil
+	case *ListValue:
+		return e.encodeList(val)
+	case *SetValue:
+		return e.encodeSet(val)
+	case *SortedSetValue:
+		return e.encodeSortedSet(val)
+	case *HashValue:
+		return e.encodeHash(val)
+	case *Stream:
+		return e.encodeStream(val)
+	case *ModuleValue:
+		return e.encodeModule(val)
+	}
+	return 0, nil, fmt.Errorf("unexpected type %T of value", v)
+}
+
+func (e *Encoder) encodeList(v *ListValue) (ValueType, []byte, error) {
+	var buf bytes.Buffer
+	if e.compact && e.version >= listpackMinVersion {
+		nodes := (len(v.Elements) + compactQuicklistNodeSize - 1) / compactQuicklistNodeSize
+		buf.Write(EncodeLength(uint64(nodes)))
+		for i := 0; i < len(v.Elements); i += compactQuicklistNodeSize {
+			end := i + compactQuicklistNodeSize
+			if end > len(v.Elements) {
+				end = len(v.Elements)
+			}
+			buf.Write(EncodeLength(quicklistNodePacked))
+			buf.Write(e.encodeString(Bytes{Val: EncodeListpack(v.Elements[i:end])}))
+		}
+		return ValueTypeListQuicklist2, buf.Bytes(), nil
+	}
+	buf.Write(EncodeLength(uint64(len(v.Elements))))
+	for _, el := range v.Elements {
+		buf.Write(e.encodeString(el))
+	}
+	return ValueTypeList, buf.Bytes(), nil
+}
+
+func (e *Encoder) encodeSet(v *SetValue) (ValueType, []byte, error) {
+	if e.compact {
+		if ints, ok := intMembers(v.Members); ok && len(ints) <= compactIntsetMaxEntries {
+			return ValueTypeIntset, e.encodeString(Bytes{Val: EncodeIntset(ints)}), nil
+		}
+		if e.version >= setListpackMinVersion && fitListpack(len(v.Members), v.Members) {
+			return ValueTypeSetListpack, e.encodeString(Bytes{Val: EncodeListpack(v.Members)}), nil
+		}
+	}
+	var buf bytes.Buffer
+	buf.Write(EncodeLength(uint64(len(v.Members))))
+	for _, m := range v.Members {
+		buf.Write(e.encodeString(m))
+	}
+	return ValueTypeSet, buf.Bytes(), nil
+}
+
+func (e *Encoder) encodeSortedSet(v *SortedSetValue) (ValueType, []byte, error) {
+	if e.compact && e.version >= listpackMinVersion {
+		elements := make([]Bytes, 0, len(v.Entries)*2)
+		for _, entry := range v.Entries {
+			elements = append(elements, entry.Member, scoreBytes(entry.Score))
+		}
+		if fitListpack(len(v.Entries), elements) {
+			return ValueTypeSortedSetListpack, e.encodeString(Bytes{Val: EncodeListpack(elements)}), nil
+		}
+	}
+	var buf bytes.Buffer
+	buf.Write(EncodeLength(uint64(len(v.Entries))))
+	for _, entry := range v.Entries {
+		buf.Write(e.encodeString(entry.Member))
+		if e.version >= zset2MinVersion {
+			buf.Write(EncodeBinaryDouble(entry.Score))
+		} else {
+			buf.Write(EncodeDouble(entry.Score))
+		}
+	}
+	if e.version >= zset2MinVersion {
+		return ValueTypeSortedSet2, buf.Bytes(), nil
+	}
+	return ValueTypeSortedSet, buf.Bytes(), nil
+}
+
+func (e *Encoder) encodeHash(v *HashValue) (ValueType, []byte, error) {
+	if e.compact && e.version >= listpackMinVersion {
+		elements := make([]Bytes, 0, len(v.Fields)*2)
+		for _, f := range v.Fields {
+			elements = append(elements, f.Field, f.Value)
+		}
+		if fitListpack(len(v.Fields), elements) {
+			return ValueTypeHashListpack, e.encodeString(Bytes{Val: EncodeListpack(elements)}), nil
+		}
+	}
+	var buf bytes.Buffer
+	buf.Write(EncodeLength(uint64(len(v.Fields))))
+	for _, f := range v.Fields {
+		buf.Write(e.encodeString(f.Field))
+		buf.Write(e.encodeString(f.Value))
+	}
+	return ValueTypeHash, buf.Bytes(), nil
+}
+
+// encodeStream write all entries to one listpack node, master entry has fields of the first entry
+func (e *Encoder) encodeStream(v *Stream) (ValueType, []byte, error) {
+	t := ValueTypeStreamListpacks
+	switch {
+	case e.version >= streamListpacks3Version:
+		t = ValueTypeStreamListpacks3
+	case e.version >= streamListpacks2Version:
+		t = ValueTypeStreamListpacks2
+	}
+
+	var buf bytes.Buffer
+	if len(v.Entries) == 0 {
+		buf.Write(EncodeLength(0))
+	} else {
+		buf.Write(EncodeLength(1))
+		masterID := v.Entries[0].ID
+		buf.Write(e.encodeString(Bytes{Val: encodeStreamID(masterID)}))
+		buf.Write(e.encodeString(Bytes{Val: EncodeListpack(streamListpackElements(masterID, v.Entries))}))
+	}
+
+	buf.Write(EncodeLength(v.Length))
+	writeID := func(id StreamID) {
+		buf.Write(EncodeLength(id.Ms))
+		buf.Write(EncodeLength(id.Seq))
+	}
+	writeID(v.LastID)
+	if t != ValueTypeStreamListpacks {
+		writeID(v.FirstID)
+		writeID(v.MaxDeletedID)
+		buf.Write(EncodeLength(v.EntriesAdded))
+	}
+
+	buf.Write(EncodeLength(uint64(len(v.Groups))))
+	for _, group := range v.Groups {
+		buf.Write(e.encodeString(Bytes{Val: []byte(group.Name)}))
+		writeID(group.LastDeliveredID)
+		if t != ValueTypeStreamListpacks {
+			buf.Write(EncodeLength(group.EntriesRead))
+		}
+		buf.Write(EncodeLength(uint64(len(group.Pending))))
+		for _, pe := range group.Pending {
+			buf.Write(encodeStreamID(pe.ID))
+			buf.Write(encodeMillisecondTime(pe.DeliveryTime))
+			buf.Write(EncodeLength(pe.DeliveryCount))
+		}
+		buf.Write(EncodeLength(uint64(len(group.Consumers))))
+		for _, consumer := range group.Consumers {
+			buf.Write(e.encodeString(Bytes{Val: []byte(consumer.Name)}))
+			buf.Write(encodeMillisecondTime(consumer.SeenTime))
+			if t == ValueTypeStreamListpacks3 {
+				activeTime := consumer.SeenTime
+				if consumer.ActiveTime != nil {
+					activeTime = *consumer.ActiveTime
+				}
+				buf.Write(encodeMillisecondTime(activeTime))
+			}
+			buf.Write(EncodeLength(uint64(len(consumer.Pending))))
+			for _, id := range consumer.Pending {
+				buf.Write(encodeStreamID(id))
+			}
+		}
+	}
+	return t, buf.Bytes(), nil
+}
+
+// streamListpackElements build elements of listpack node: master entry and entries with diffs of ids
+func streamListpackElements(masterID StreamID, entries []*StreamEntry) []Bytes {
+	masterFields := entries[0].Fields
+	res := []Bytes{
+		NewIntBytes(int64(len(entries))),
+		NewIntBytes(0),
+		NewIntBytes(int64(len(masterFields))),
+	}
+	for _, f := range masterFields {
+		res = append(res, f.Field)
+	}
+	res = append(res, NewIntBytes(0))
+
+	for _, entry := range entries {
+		sameFields := sameStreamFields(masterFields, entry.Fields)
+		flags := int64(0)
+		if sameFields {
+			flags = streamItemFlagSameFields
+		}
+		res = append(res,
+			NewIntBytes(flags),
+			NewIntBytes(int64(entry.ID.Ms-masterID.Ms)),
+			NewIntBytes(int64(entry.ID.Seq-masterID.Seq)),
+		)
+		// lp-count is number of elements of entry without itself
+		lpCount := 3 + len(entry.Fields)
+		if sameFields {
+			for _, f := range entry.Fields {
+				res = append(res, f.Value)
+			}
+		} else {
+			lpCount += len(entry.Fields) + 1
+			res = append(res, NewIntBytes(int64(len(entry.Fields))))
+			for _, f := range entry.Fields {
+				res = append(res, f.Field, f.Value)
+			}
+		}
+		res = append(res, NewIntBytes(int64(lpCount)))
+	}
+	return res
+}
+
+func sameStreamFields(master []StreamField, fields []StreamField) bool {
+	if len(master) != len(fields) {
+		return false
+	}
+	for i := range master {
+		if !bytes.Equal(master[i].Field.Val, fields[i].Field.Val) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *Encoder) encodeModule(v *ModuleValue) (ValueType, []byte, error) {
+	var buf bytes.Buffer
+	buf.Write(EncodeLength(v.ID))
+	for _, d := range v.Data {
+		switch val := d.(type) {
+		case uint64:
+			buf.Write(EncodeLength(moduleOpCodeUInt))
+			buf.Write(EncodeLength(val))
+		case float32:
+			buf.Write(EncodeLength(moduleOpCodeFloat))
+			b := make([]byte, 4)
+			binary.LittleEndian.PutUint32(b, math.Float32bits(val))
+			buf.Write(b)
+		case float64:
+			buf.Write(EncodeLength(moduleOpCodeDouble))
+			buf.Write(EncodeBinaryDouble(val))
+		case Bytes:
+			buf.Write(EncodeLength(moduleOpCodeString))
+			buf.Write(e.encodeString(val))
+		default:
+			return 0, nil, fmt.Errorf("unexpected type %T of module data", d)
+		}
+	}
+	buf.Write(EncodeLength(moduleOpCodeEOF))
+	return ValueTypeModule2, buf.Bytes(), nil
+}
+
+// intMembers return members as ints if all of them are ints
+func intMembers(members []Bytes) ([]int64, bool) {
+	res := make([]int64, 0, len(members))
+	for _, m := range members {
+		v, err := m.Int()
+		if err != nil || (!m.IsInt && strconv.FormatInt(v, 10) != string(m.Val)) {
+			return nil, false
+		}
+		res = append(res, v)
+	}
+	return res, true
+}
+
+func fitListpack(entries int, elements []Bytes) bool {
+	if entries > compactListpackMaxEntries {
+		return false
+	}
+	for _, el := range elements {
+		if !el.IsInt && len(el.Val) > compactListpackMaxValue {
+			return false
+		}
+	}
+	return true
+}
+
+// scoreBytes format score for listpack, integer scores are saved as ints like in redis
+func scoreBytes(score float64) Bytes {
+	if score == math.Trunc(score) && math.Abs(score) < 1<<53 {
+		return NewIntBytes(int64(score))
+	}
+	return Bytes{Val: []byte(strconv.FormatFloat(score, 'g', 17, 64))}
+}
+
+func encodeStreamID(id StreamID) []byte {
+	res := make([]byte, streamIDLen)
+	binary.BigEndian.PutUint64(res[0:8], id.Ms)
+	binary.BigEndian.PutUint64(res[8:16], id.Seq)
+	return res
+}
+
+// encodeMillisecondTime encode unix time in milliseconds as 8 bytes little endian
+func encodeMillisecondTime(t time.Time) []byte {
+	res := make([]byte, 8)
+	binary.LittleEndian.PutUint64(res, uint64(unixMilli(t)))
+	return res
+}
@@ -0,0 +1,317 @@
+package rdb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func encoderTestRows() []*Row {
+	expiry := time.Unix(1700000000, 123*int64(time.Millisecond))
+	seen := time.Unix(1700000001, 0)
+	long := Bytes{Val: []byte(strings.Repeat("abc", 30))}
+	return []*Row{
+		{Key: "str", RawKey: Bytes{Val: []byte("str")}, Expiry: &expiry, Value: &StringValue{Val: long}},
+		{Key: "int", RawKey: Bytes{Val: []byte("int")}, Value: &StringValue{Val: NewIntBytes(-100000)}},
+		{Key: "list", RawKey: Bytes{Val: []byte("list")}, Value: &ListValue{Elements: []Bytes{
+			{Val: []byte("a")}, NewIntBytes(7), long,
+		}}},
+		{Key: "set", RawKey: Bytes{Val: []byte("set")}, Value: &SetValue{Members: []Bytes{
+			{Val: []byte("a")}, {Val: []byte("b")},
+		}}},
+		{Key: "intset", RawKey: Bytes{Val: []byte("intset")}, Value: &SetValue{Members: []Bytes{
+			NewIntBytes(-5), NewIntBytes(1), NewIntBytes(70000),
+		}}},
+		{Key: "zset", RawKey: Bytes{Val: []byte("zset")}, Value: &SortedSetValue{Entries: []SortedSetEntry{
+			{Member: Bytes{Val: []byte("a")}, Score: 1},
+			{Member: Bytes{Val: []byte("b")}, Score: 2.5},
+		}}},
+		{Key: "hash", RawKey: Bytes{Val: []byte("hash")}, Value: &HashValue{Fields: []HashField{
+			{Field: Bytes{Val: []byte("f")}, Value: Bytes{Val: []byte("v")}},
+		}}},
+		{Key: "stream", RawKey: Bytes{Val: []byte("stream")}, Value: &Stream{
+			Entries: []*StreamEntry{
+				{ID: StreamID{Ms: 1000, Seq: 0}, Fields: []StreamField{{Field: Bytes{Val: []byte("a")}, Value: Bytes{Val: []byte("1")}}}},
+				{ID: StreamID{Ms: 1000, Seq: 1}, Fields: []StreamField{{Field: Bytes{Val: []byte("a")}, Value: Bytes{Val: []byte("2")}}}},
+				{ID: StreamID{Ms: 1500, Seq: 0}, Fields: []StreamField{{Field: Bytes{Val: []byte("b")}, Value: Bytes{Val: []byte("x")}}, {Field: Bytes{Val: []byte("c")}, Value: Bytes{Val: []byte("y")}}}},
+			},
+			Length: 3,
+			LastID: StreamID{Ms: 1500, Seq: 0},
+			Groups: []*StreamConsumerGroup{{
+				Name:            "g",
+				LastDeliveredID: StreamID{Ms: 1000, Seq: 1},
+				Pending:         []*StreamPendingEntry{{ID: StreamID{Ms: 1000, Seq: 1}, DeliveryTime: seen, DeliveryCount: 1}},
+				Consumers:       []*StreamConsumer{{Name: "c", SeenTime: seen, Pending: []StreamID{{Ms: 1000, Seq: 1}}}},
+			}},
+		}},
+	}
+}
+
+func TestEncoder_GivenRows_DecodedRowsAreEqual(t *testing.T) {
+	r := require.New(t)
+	type testData struct {
+		version string
+		opts    []EncoderOption
+	}
+	dp := []testData{
+		{version: "0006"},
+		{version: "0009"},
+		{version: "0009", opts: []EncoderOption{WithCompression()}},
+		{version: "0009", opts: []EncoderOption{WithCompactEncodings()}},
+		{version: "0010", opts: []EncoderOption{WithCompactEncodings()}},
+		{version: "0011", opts: []EncoderOption{WithCompactEncodings(), WithCompression()}},
+	}
+
+	for k, data := range dp {
+		t.Run(fmt.Sprintf("Data provider #%d", k), func(t *testing.T) {
+			rows := encoderTestRows()
+			buf := &bytes.Buffer{}
+			e := NewEncoder(buf, data.opts...)
+			e.RDBVersion(data.version)
+			e.AuxiliaryField(AuxiliaryField{K: "redis-ver", V: "5.0.4"})
+			e.SelectDB(2)
+			e.ResizeDB(uint32(len(rows)), 1)
+			for _, row := range rows {
+				e.Row(row)
+			}
+			e.End(nil)
+			r.NoError(e.Err())
+
+			c := &testConsumer{}
+			r.NoError(NewDecoder(buf, c).Decode())
+			r.Equal(data.version, c.version)
+			r.Equal([]AuxiliaryField{{K: "redis-ver", V: "5.0.4"}}, c.aux)
+			r.Equal([]uint32{2}, c.dbs)
+			r.Len(c.rows, len(rows))
+			for i, row := range c.rows {
+				r.Equal(rows[i].Key, row.Key)
+				r.Equal(rows[i].Kind(), row.Kind())
+				if rows[i].Expiry == nil {
+					r.Nil(row.Expiry)
+				} else {
+					r.NotNil(row.Expiry)
+					r.True(rows[i].Expiry.Equal(*row.Expiry))
+				}
+			}
+			r.Equal(rows[0].Value, c.rows[0].Value)
+			r.Equal(rows[1].Value, c.rows[1].Value)
+			list, _ := c.rows[2].AsList()
+			r.Equal([]string{"a", "7", strings.Repeat("abc", 30)}, list.Strings())
+			set, _ := c.rows[3].AsSet()
+			r.ElementsMatch([]string{"a", "b"}, set.Strings())
+			intset, _ := c.rows[4].AsSet()
+			r.ElementsMatch([]string{"-5", "1", "70000"}, intset.Strings())
+			zset, _ := c.rows[5].AsSortedSet()
+			r.Equal(map[string]float64{"a": 1, "b": 2.5}, zset.Scores())
+			hash, _ := c.rows[6].AsHash()
+			r.Equal(map[string]string{"f": "v"}, hash.Map())
+			stream, _ := c.rows[7].AsStream()
+			expected := rows[7].Value.(*Stream)
+			r.Equal(expected.Entries, stream.Entries)
+			r.Equal(expected.Length, stream.Length)
+			r.Equal(expected.LastID, stream.LastID)
+			r.Len(stream.Groups, 1)
+			r.Equal(expected.Groups[0].Pending[0].ID, stream.Groups[0].Pending[0].ID)
+			r.Equal(expected.Groups[0].Consumers[0].Pending, stream.Groups[0].Consumers[0].Pending)
+		})
+	}
+}
+
+func TestEncoder_GivenCompactEncodings_CompactTypes(t *testing.T) {
+	r := require.New(t)
+	buf := &bytes.Buffer{}
+	e := NewEncoder(buf, WithCompactEncodings())
+	e.RDBVersion("0011")
+	for _, row := range encoderTestRows() {
+		e.Row(row)
+	}
+	e.End(nil)
+	r.NoError(e.Err())
+
+	c := &testConsumer{}
+	r.NoError(NewDecoder(buf, c).Decode())
+	types := make([]ValueType, 0, len(c.rows))
+	for _, row := range c.rows {
+		types = append(types, row.Type)
+	}
+	r.Equal([]ValueType{
+		ValueTypeString, ValueTypeString, ValueTypeListQuicklist2, ValueTypeSetListpack, ValueTypeIntset,
+		ValueTypeSortedSetListpack, ValueTypeHashListpack, ValueTypeStreamListpacks3,
+	}, types)
+}
+
+func TestEncoder_GivenDecodedRDB_SameRows(t *testing.T) {
+	r := require.New(t)
+	data, err := ioutil.ReadFile("../e2e/rdb/rdb")
+	r.NoError(err)
+	source := &testConsumer{}
+	r.NoError(NewDecoder(bytes.NewBuffer(data), source).Decode())
+
+	buf := &bytes.Buffer{}
+	e := NewEncoder(buf, WithCompression())
+	r.NoError(NewDecoder(bytes.NewBuffer(data), e).Decode())
+	r.NoError(e.Err())
+
+	c := &testConsumer{}
+	r.NoError(NewDecoder(buf, c).Decode())
+	r.Equal(source.version, c.version)
+	r.Equal(source.dbs, c.dbs)
+	r.Len(c.rows, len(source.rows))
+	for i, row := range c.rows {
+		r.Equal(source.rows[i].Key, row.Key)
+		r.Equal(source.rows[i].Kind(), row.Kind())
+	}
+}
+
+type failWriter struct{}
+
+func (failWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestEncoder_GivenWriteError_Err(t *testing.T) {
+	r := require.New(t)
+	e := NewEncoder(failWriter{})
+	e.RDBVersion("0009")
+	e.SelectDB(0)
+	e.End(nil)
+	r.EqualError(e.Err(), "write failed")
+}
+
+func TestEncoder_GivenRowsWithoutVersion_DefaultVersion(t *testing.T) {
+	r := require.New(t)
+	buf := &bytes.Buffer{}
+	e := NewEncoder(buf)
+	e.Row(&Row{Key: "a", Value: &StringValue{Val: Bytes{Val: []byte("b")}}})
+	e.End(nil)
+	r.NoError(e.Err())
+	r.True(bytes.HasPrefix(buf.Bytes(), []byte("REDIS"+DefaultEncoderVersion)))
+
+	c := &testConsumer{}
+	r.NoError(NewDecoder(buf, c).Decode())
+	r.Len(c.rows, 1)
+	r.Equal("a", c.rows[0].Key)
+}
+
+func TestEncoder_GivenIdleAndFreq_WrittenSinceVersion9(t *testing.T) {
+	r := require.New(t)
+	type testData struct {
+		version      string
+		expectedIdle *uint64
+		expectedFreq *uint8
+	}
+	idle := uint64(300)
+	freq := uint8(5)
+	dp := []testData{
+		{version: "0009", expectedIdle: &idle, expectedFreq: &freq},
+		{version: "0011", expectedIdle: &idle, expectedFreq: &freq},
+		{version: "0008"},
+	}
+
+	for k, data := range dp {
+		t.Run(fmt.Sprintf("Data provider #%d", k), func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			e := NewEncoder(buf)
+			e.RDBVersion(data.version)
+			e.SelectDB(0)
+			e.Row(&Row{Key: "k", RawKey: Bytes{Val: []byte("k")}, Idle: &idle, Freq: &freq, Value: &StringValue{Val: Bytes{Val: []byte("v")}}})
+			e.End(nil)
+			r.NoError(e.Err())
+
+			c := &testConsumer{}
+			r.NoError(NewDecoder(buf, c).Decode())
+			r.Len(c.rows, 1)
+			r.Equal(data.expectedIdle, c.rows[0].Idle)
+			r.Equal(data.expectedFreq, c.rows[0].Freq)
+		})
+	}
+}
+
+func TestEncoder_GivenModuleAuxAndFunction_DecodedEqual(t *testing.T) {
+	r := require.New(t)
+	aux := &ModuleAux{
+		ID:     moduleTypeID("scripting", 1),
+		Name:   "scripting",
+		EncVer: 1,
+		When:   2,
+		Data:   []interface{}{uint64(7), Bytes{Val: []byte("ok")}},
+	}
+
+	buf := &bytes.Buffer{}
+	e := NewEncoder(buf)
+	e.RDBVersion("0010")
+	e.ModuleAux(aux)
+	e.Function([]byte("code"))
+	e.End(nil)
+	r.NoError(e.Err())
+
+	c := &testExtraConsumer{}
+	r.NoError(NewDecoder(buf, c).Decode())
+	r.Equal([]*ModuleAux{aux}, c.moduleAux)
+	r.Equal([][]byte{[]byte("code")}, c.functions)
+}
+
+func TestEncoder_GivenStreamWithIntFields_SameFields(t *testing.T) {
+	r := require.New(t)
+	type testData struct {
+		version string
+	}
+	dp := []testData{
+		{version: "0009"},
+		{version: "0010"},
+		{version: "0011"},
+	}
+	entries := []*StreamEntry{
+		// int encoded field and value and string "7", which looks like int
+		{ID: StreamID{Ms: 1000}, Fields: []StreamField{
+			{Field: NewIntBytes(7), Value: NewIntBytes(-5)},
+			{Field: Bytes{Val: []byte("s")}, Value: Bytes{Val: []byte("7")}},
+		}},
+		{ID: StreamID{Ms: 1001}, Fields: []StreamField{
+			{Field: NewIntBytes(7), Value: Bytes{Val: []byte("-5")}},
+			{Field: Bytes{Val: []byte("s")}, Value: NewIntBytes(123456789012)},
+		}},
+		{ID: StreamID{Ms: 1002}, Fields: []StreamField{
+			{Field: Bytes{Val: []byte("7")}, Value: NewIntBytes(0)},
+		}},
+	}
+
+	for k, data := range dp {
+		t.Run(fmt.Sprintf("Data provider #%d", k), func(t *testing.T) {
+			row := &Row{Key: "s", RawKey: Bytes{Val: []byte("s")}, Value: &Stream{
+				Entries: entries,
+				Length:  3,
+				LastID:  StreamID{Ms: 1002},
+			}}
+			buf := &bytes.Buffer{}
+			e := NewEncoder(buf)
+			e.RDBVersion(data.version)
+			e.Row(row)
+			e.End(nil)
+			r.NoError(e.Err())
+			encoded := append([]byte{}, buf.Bytes()...)
+
+			c := &testConsumer{}
+			r.NoError(NewDecoder(buf, c).Decode())
+			r.Len(c.rows, 1)
+			stream, ok := c.rows[0].AsStream()
+			r.True(ok)
+			r.Equal(entries, stream.Entries)
+
+			// decoded row is encoded to the same bytes
+			again := &bytes.Buffer{}
+			e = NewEncoder(again)
+			e.RDBVersion(data.version)
+			e.Row(c.rows[0])
+			e.End(nil)
+			r.NoError(e.Err())
+			r.Equal(encoded, again.Bytes())
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package rdb
+
+import (
+	"regexp"
+	"time"
+)
+
+// RowFilter decide by header of row whether row must be decoded, row contains key, type and expiry without value.
+// Value of not matched row is skipped without decoding, consumer doesn't get this row.
+// now is clock of decoder, which is set by WithClock.
+type RowFilter interface {
+	Match(db uint32, row *Row, now func() time.Time) bool
+}
+
+type RowFilterFunc func(db uint32, row *Row, now func() time.Time) bool
+
+func (f RowFilterFunc) Match(db uint32, row *Row, now func() time.Time) bool {
+	return f(db, row, now)
+}
+
+// ExpiryMatch is condition for expiry of key
+type ExpiryMatch int
+
+const (
+	ExpiryAny ExpiryMatch = iota
+	ExpiryExpired
+	ExpiryNotExpired
+)
+
+// KeyFilter match rows by all set conditions, empty condition matches any row.
+// Key matches if it matches any of globs or regexps.
+type KeyFilter struct {
+	DBs     []uint32
+	Globs   []string
+	Regexps []*regexp.Regexp
+	Kinds   []Kind
+	// Expiry is checked by clock of decoder
+	Expiry ExpiryMatch
+}
+
+func (f *KeyFilter) Match(db uint32, row *Row, now func() time.Time) bool {
+	return f.matchDB(db) && f.matchKind(row.Type.Kind()) && f.matchExpiry(row.Expiry, now) && f.matchKey(row.Key)
+}
+
+func (f *KeyFilter) matchDB(db uint32) bool {
+	if len(f.DBs) == 0 {
+		return true
+	}
+	for _, v := range f.DBs {
+		if v == db {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *KeyFilter) matchKind(kind Kind) bool {
+	if len(f.Kinds) == 0 {
+		return true
+	}
+	for _, v := range f.Kinds {
+		if v == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *KeyFilter) matchExpiry(expiry *time.Time, now func() time.Time) bool {
+	if f.Expiry == ExpiryAny {
+		return true
+	}
+	expired := expiry != nil && now().After(*expiry)
+	return expired == (f.Expiry == ExpiryExpired)
+}
+
+func (f *KeyFilter) matchKey(key string) bool {
+	if len(f.Globs) == 0 && len(f.Regexps) == 0 {
+		return true
+	}
+	for _, g := range f.Globs {
+		if GlobMatch(g, key) {
+			return true
+		}
+	}
+	for _, re := range f.Regexps {
+		if re.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
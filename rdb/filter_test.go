@@ -0,0 +1,124 @@
+package rdb
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyFilter_Match(t *testing.T) {
+	r := require.New(t)
+	now := time.Unix(1000, 0)
+	clock := func() time.Time { return now }
+	past := time.Unix(999, 0)
+	future := time.Unix(1001, 0)
+	str := &Row{Key: "user:1", Type: ValueTypeString}
+	hash := &Row{Key: "session:1", Type: ValueTypeHashListpack, Expiry: &future}
+	expired := &Row{Key: "user:2", Type: ValueTypeString, Expiry: &past}
+
+	type testData struct {
+		filter      *KeyFilter
+		db          uint32
+		row         *Row
+		expectedRes bool
+	}
+	dp := []testData{
+		{filter: &KeyFilter{}, row: str, expectedRes: true},
+		{filter: &KeyFilter{DBs: []uint32{1, 2}}, db: 2, row: str, expectedRes: true},
+		{filter: &KeyFilter{DBs: []uint32{1, 2}}, db: 0, row: str, expectedRes: false},
+		{filter: &KeyFilter{Globs: []string{"user:*"}}, row: str, expectedRes: true},
+		{filter: &KeyFilter{Globs: []string{"user:*"}}, row: hash, expectedRes: false},
+		// key matches any of patterns
+		{filter: &KeyFilter{Globs: []string{"user:*"}, Regexps: []*regexp.Regexp{regexp.MustCompile(`^session:\d+$`)}}, row: hash, expectedRes: true},
+		{filter: &KeyFilter{Kinds: []Kind{KindHash}}, row: hash, expectedRes: true},
+		{filter: &KeyFilter{Kinds: []Kind{KindHash}}, row: str, expectedRes: false},
+		{filter: &KeyFilter{Expiry: ExpiryExpired}, row: expired, expectedRes: true},
+		{filter: &KeyFilter{Expiry: ExpiryExpired}, row: hash, expectedRes: false},
+		{filter: &KeyFilter{Expiry: ExpiryNotExpired}, row: str, expectedRes: true},
+		{filter: &KeyFilter{Expiry: ExpiryNotExpired}, row: expired, expectedRes: false},
+	}
+
+	for k, data := range dp {
+		t.Run(fmt.Sprintf("Data provider #%d", k), func(t *testing.T) {
+			r.Equal(data.expectedRes, data.filter.Match(data.db, data.row, clock))
+		})
+	}
+}
+
+func TestDecoder_Decode_GivenFilter_SkippedValuesOfAllTypes(t *testing.T) {
+	r := require.New(t)
+	rows := append(encoderTestRows(), &Row{Key: "module", Value: &ModuleValue{
+		ID:   moduleTypeID("mymodule1", 2),
+		Data: []interface{}{uint64(1), float32(1.5), 2.5, Bytes{Val: []byte("data")}},
+	}})
+	type testData struct {
+		version string
+		opts    []EncoderOption
+	}
+	dp := []testData{
+		{version: "0006"},
+		{version: "0009", opts: []EncoderOption{WithCompression()}},
+		{version: "0010", opts: []EncoderOption{WithCompactEncodings()}},
+		{version: "0011", opts: []EncoderOption{WithCompactEncodings()}},
+	}
+
+	for k, data := range dp {
+		t.Run(fmt.Sprintf("Data provider #%d", k), func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			e := NewEncoder(buf, data.opts...)
+			e.RDBVersion(data.version)
+			for _, row := range rows {
+				e.Row(row)
+				e.Row(&Row{Key: "marker", Value: &StringValue{Val: Bytes{Val: []byte(row.Key)}}})
+			}
+			e.End(nil)
+			r.NoError(e.Err())
+
+			c := &testConsumer{}
+			filter := &KeyFilter{Globs: []string{"marker"}}
+			r.NoError(NewDecoder(buf, c, WithFilter(filter)).Decode())
+			r.Len(c.rows, len(rows))
+			for i, row := range c.rows {
+				r.Equal("marker", row.Key)
+				r.Equal(&StringValue{Val: Bytes{Val: []byte(rows[i].Key)}}, row.Value)
+			}
+		})
+	}
+}
+
+func TestDecoder_Decode_GivenFilterByDB_RowsOfDB(t *testing.T) {
+	r := require.New(t)
+	data, err := ioutil.ReadFile("../e2e/rdb/rdb")
+	r.NoError(err)
+
+	c := &testConsumer{}
+	r.NoError(NewDecoder(bytes.NewBuffer(data), c, WithFilter(&KeyFilter{DBs: []uint32{1}})).Decode())
+	r.Empty(c.rows)
+
+	c = &testConsumer{}
+	r.NoError(NewDecoder(bytes.NewBuffer(data), c, WithFilter(&KeyFilter{Globs: []string{"u:*"}})).Decode())
+	r.Len(c.rows, 36)
+}
+
+func TestDecoder_Decode_GivenFilterAndElementConsumer_MatchedRows(t *testing.T) {
+	r := require.New(t)
+	buf := &bytes.Buffer{}
+	e := NewEncoder(buf)
+	e.Row(&Row{Key: "a", Value: &ListValue{Elements: []Bytes{{Val: []byte("1")}}}})
+	e.Row(&Row{Key: "b", Value: &ListValue{Elements: []Bytes{{Val: []byte("2")}}}})
+	e.End(nil)
+	r.NoError(e.Err())
+
+	c := &testElementConsumer{}
+	filter := RowFilterFunc(func(db uint32, row *Row, now func() time.Time) bool {
+		return row.Key == "b"
+	})
+	r.NoError(NewDecoder(buf, c, WithFilter(filter)).Decode())
+	r.Equal([]string{"b"}, c.begin)
+	r.Equal([]string{"b"}, c.end)
+}
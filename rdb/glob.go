@@ -0,0 +1,89 @@
+package rdb
+
+// GlobMatch match string with glob-style pattern like KEYS command of redis:
+// * any sequence, ? any symbol, [abc], [^abc] and [a-z] classes, \ escapes special symbol
+// Docs https://github.com/redis/redis/blob/unstable/src/util.c, see stringmatchlen
+func GlobMatch(pattern, s string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(s); i++ {
+				if GlobMatch(pattern[1:], s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+			s = s[1:]
+		case '[':
+			if len(s) == 0 {
+				return false
+			}
+			var match bool
+			pattern, match = matchClass(pattern[1:], s[0])
+			if !match {
+				return false
+			}
+			s = s[1:]
+			// unterminated class matches until the end of pattern
+			if len(pattern) == 0 {
+				return len(s) == 0
+			}
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(s) == 0 || pattern[0] != s[0] {
+				return false
+			}
+			s = s[1:]
+		}
+		pattern = pattern[1:]
+	}
+	return len(s) == 0
+}
+
+// matchClass match symbol with class after '[', it returns pattern started from ']'
+func matchClass(pattern string, c byte) (string, bool) {
+	not := len(pattern) > 0 && pattern[0] == '^'
+	if not {
+		pattern = pattern[1:]
+	}
+	match := false
+	for len(pattern) > 0 && pattern[0] != ']' {
+		switch {
+		case pattern[0] == '\\' && len(pattern) > 1:
+			pattern = pattern[1:]
+			if pattern[0] == c {
+				match = true
+			}
+		case len(pattern) > 2 && pattern[1] == '-':
+			start, end := pattern[0], pattern[2]
+			if start > end {
+				start, end = end, start
+			}
+			if c >= start && c <= end {
+				match = true
+			}
+			pattern = pattern[2:]
+		case pattern[0] == c:
+			match = true
+		}
+		pattern = pattern[1:]
+	}
+	if not {
+		match = !match
+	}
+	return pattern, match
+}
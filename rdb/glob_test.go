@@ -0,0 +1,46 @@
+package rdb
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGlobMatch(t *testing.T) {
+	r := require.New(t)
+	type testData struct {
+		pattern     string
+		s           string
+		expectedRes bool
+	}
+	dp := []testData{
+		{pattern: "*", s: "", expectedRes: true},
+		{pattern: "*", s: "abc", expectedRes: true},
+		{pattern: "user:*", s: "user:1", expectedRes: true},
+		{pattern: "user:*", s: "users:1", expectedRes: false},
+		{pattern: "*:1", s: "user:1", expectedRes: true},
+		{pattern: "a*b*c", s: "axxbyyc", expectedRes: true},
+		{pattern: "a*b*c", s: "axxbyy", expectedRes: false},
+		{pattern: "h?llo", s: "hello", expectedRes: true},
+		{pattern: "h?llo", s: "hllo", expectedRes: false},
+		{pattern: "h[ae]llo", s: "hallo", expectedRes: true},
+		{pattern: "h[ae]llo", s: "hillo", expectedRes: false},
+		{pattern: "h[^e]llo", s: "hallo", expectedRes: true},
+		{pattern: "h[^e]llo", s: "hello", expectedRes: false},
+		{pattern: "h[a-b]llo", s: "hbllo", expectedRes: true},
+		{pattern: "h[b-a]llo", s: "hbllo", expectedRes: true},
+		{pattern: "h[a-b]llo", s: "hcllo", expectedRes: false},
+		{pattern: `h\*llo`, s: "h*llo", expectedRes: true},
+		{pattern: `h\*llo`, s: "hello", expectedRes: false},
+		{pattern: `[\]]`, s: "]", expectedRes: true},
+		{pattern: "abc", s: "abcd", expectedRes: false},
+		{pattern: "", s: "", expectedRes: true},
+	}
+
+	for k, data := range dp {
+		t.Run(fmt.Sprintf("Data provider #%d", k), func(t *testing.T) {
+			r.Equal(data.expectedRes, GlobMatch(data.pattern, data.s))
+		})
+	}
+}
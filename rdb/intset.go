@@ -0,0 +1,79 @@
+package rdb
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"sort"
+)
+
+const intsetHeaderLen = 8
+
+var (
+	ErrIntsetUnexpectedEncoding = errors.New("unexpected encoding of intset")
+	ErrIntsetBadLength          = errors.New("length of intset is bad")
+)
+
+// DecodeIntset decode intset blob to ints
+// Docs https://rdb.fnordig.de/file_format.html#intset-encoding
+func DecodeIntset(data []byte) ([]int, error) {
+	if len(data) < intsetHeaderLen {
+		return nil, ErrIntsetBadLength
+	}
+	encoding := binary.LittleEndian.Uint32(data[0:4])
+	switch encoding {
+	case 2, 4, 8:
+	default:
+		return nil, ErrIntsetUnexpectedEncoding
+	}
+	length := binary.LittleEndian.Uint32(data[4:8])
+	if uint64(len(data)-intsetHeaderLen) != uint64(encoding)*uint64(length) {
+		return nil, ErrIntsetBadLength
+	}
+
+	res := make([]int, 0, length)
+	for pos := intsetHeaderLen; pos < len(data); pos += int(encoding) {
+		switch encoding {
+		case 2:
+			res = append(res, int(int16(binary.LittleEndian.Uint16(data[pos:]))))
+		case 4:
+			res = append(res, int(int32(binary.LittleEndian.Uint32(data[pos:]))))
+		case 8:
+			res = append(res, int(int64(binary.LittleEndian.Uint64(data[pos:]))))
+		}
+	}
+	return res, nil
+}
+
+// EncodeIntset encode ints to intset blob with minimal encoding, ints are sorted like in redis
+func EncodeIntset(ints []int64) []byte {
+	sorted := make([]int64, len(ints))
+	copy(sorted, ints)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	encoding := 2
+	for _, v := range sorted {
+		switch {
+		case v < math.MinInt32 || v > math.MaxInt32:
+			encoding = 8
+		case (v < math.MinInt16 || v > math.MaxInt16) && encoding < 4:
+			encoding = 4
+		}
+	}
+
+	data := make([]byte, intsetHeaderLen+encoding*len(sorted))
+	binary.LittleEndian.PutUint32(data[0:4], uint32(encoding))
+	binary.LittleEndian.PutUint32(data[4:8], uint32(len(sorted)))
+	for i, v := range sorted {
+		pos := intsetHeaderLen + i*encoding
+		switch encoding {
+		case 2:
+			binary.LittleEndian.PutUint16(data[pos:], uint16(v))
+		case 4:
+			binary.LittleEndian.PutUint32(data[pos:], uint32(v))
+		case 8:
+			binary.LittleEndian.PutUint64(data[pos:], uint64(v))
+		}
+	}
+	return data
+}
@@ -0,0 +1,76 @@
+package rdb
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeIntset_GivenCorrectIntset_Ints(t *testing.T) {
+	r := require.New(t)
+	type testData struct {
+		data        []byte
+		expectedRes []int
+	}
+	dp := []testData{
+		{
+			data:        []byte{0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+			expectedRes: []int{},
+		},
+		{
+			data: []byte{
+				0x02, 0x00, 0x00, 0x00, // encoding
+				0x02, 0x00, 0x00, 0x00, // length
+				0xFF, 0xFF, 0x39, 0x30,
+			},
+			expectedRes: []int{-1, 12345},
+		},
+		{
+			data: []byte{
+				0x04, 0x00, 0x00, 0x00, // encoding
+				0x01, 0x00, 0x00, 0x00, // length
+				0x7A, 0x3C, 0xC3, 0x5C,
+			},
+			expectedRes: []int{1556298874},
+		},
+		{
+			data: []byte{
+				0x08, 0x00, 0x00, 0x00, // encoding
+				0x01, 0x00, 0x00, 0x00, // length
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x80,
+			},
+			expectedRes: []int{-1 << 63},
+		},
+	}
+
+	for k, data := range dp {
+		t.Run(fmt.Sprintf("Data provider #%d", k), func(t *testing.T) {
+			res, err := DecodeIntset(data.data)
+			r.NoError(err)
+			r.Equal(data.expectedRes, res)
+		})
+	}
+}
+
+func TestDecodeIntset_GivenBadIntset_Err(t *testing.T) {
+	r := require.New(t)
+	type testData struct {
+		data []byte
+		err  error
+	}
+	dp := []testData{
+		{data: []byte{0x02, 0x00, 0x00, 0x00}, err: ErrIntsetBadLength},
+		{data: []byte{0x03, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, err: ErrIntsetUnexpectedEncoding},
+		{data: []byte{0x02, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x01, 0x00}, err: ErrIntsetBadLength},
+	}
+
+	for k, data := range dp {
+		t.Run(fmt.Sprintf("Data provider #%d", k), func(t *testing.T) {
+			res, err := DecodeIntset(data.data)
+			r.Error(err)
+			r.Nil(res)
+			r.Equal(data.err, err)
+		})
+	}
+}
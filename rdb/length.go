@@ -4,6 +4,7 @@ import (
 	"encoding/binary"
 	"errors"
 	"io"
+	"math"
 )
 
 type LengthType int
@@ -14,11 +15,16 @@ const (
 	LengthType4Bytes
 	LengthTypeEncodedStringInt
 	LengthTypeEncodedStringCompressedStr
+	LengthType8Bytes
 )
 
+// length64BitsPrefix is first byte of length encoded with next 8 bytes
+const length64BitsPrefix = 0x81
+
 type Length struct {
 	t            LengthType
 	length       uint32
+	length64     uint64
 	verifyLength uint32
 }
 
@@ -34,6 +40,14 @@ func (l *Length) GetLength() uint32 {
 	return l.length
 }
 
+// GetLength64 return length, which can be encoded with 8 bytes, e.g. stream ids and counters
+func (l *Length) GetLength64() uint64 {
+	if l.t == LengthType8Bytes {
+		return l.length64
+	}
+	return uint64(l.length)
+}
+
 func (l *Length) CheckVerifyLength(expected uint32) error {
 	if expected != l.verifyLength {
 		return errors.New("verify length for encoded string isn't expected")
@@ -75,6 +89,23 @@ func decodeLength(r ByteReader, isEncodedLengthRead bool) (*Length, error) {
 			length: uint32(b&0x3F)<<8 | uint32(b2),
 		}, nil
 	case 0x2: // 10 prefix
+		if b == length64BitsPrefix {
+			bytes := make([]byte, 8)
+			if _, err := io.ReadFull(r, bytes); err != nil {
+				return nil, err
+			}
+			length64 := binary.BigEndian.Uint64(bytes)
+			// GetLength can't return 8 bytes length, so it's saturated
+			length := uint32(math.MaxUint32)
+			if length64 < math.MaxUint32 {
+				length = uint32(length64)
+			}
+			return &Length{
+				t:        LengthType8Bytes,
+				length:   length,
+				length64: length64,
+			}, nil
+		}
 		bytes := make([]byte, 4)
 		if _, err := io.ReadFull(r, bytes); err != nil {
 			return nil, err
@@ -118,3 +149,20 @@ func decodeLength(r ByteReader, isEncodedLengthRead bool) (*Length, error) {
 	}
 	return nil, errors.New("unexpected prefix of length") // it will not can happen
 }
+
+// EncodeLength encode length with minimal number of bytes
+func EncodeLength(l uint64) []byte {
+	switch {
+	case l < 1<<6:
+		return []byte{byte(l)}
+	case l < 1<<14:
+		return []byte{0x40 | byte(l>>8), byte(l)}
+	case l <= math.MaxUint32:
+		res := []byte{0x80, 0, 0, 0, 0}
+		binary.BigEndian.PutUint32(res[1:], uint32(l))
+		return res
+	}
+	res := []byte{length64BitsPrefix, 0, 0, 0, 0, 0, 0, 0, 0}
+	binary.BigEndian.PutUint64(res[1:], l)
+	return res
+}
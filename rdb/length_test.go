@@ -139,7 +139,7 @@ func TestDecodeLength_GivenEncodedStringIntLengthBytePrefix_LenOfInt(t *testing.
 	dp := []testData{
 		{data: []byte{0xC0}, expectedRes: 1},
 		{data: []byte{0xC1}, expectedRes: 2},
-		{data: []byte{0xC2}, expectedRes: 3},
+		{data: []byte{0xC2}, expectedRes: 4},
 	}
 
 	for k, data := range dp {
@@ -237,3 +237,53 @@ func TestDecodeLength_GivenPrefixEncodedStringWithNextTimeReturnEncodedString_Er
 		})
 	}
 }
+
+func TestDecodeLength_Given8BytesLengthBytePrefix_8BytesLength(t *testing.T) {
+	r := require.New(t)
+	type testData struct {
+		data          []byte
+		expectedRes   uint32
+		expectedRes64 uint64
+	}
+	dp := []testData{
+		{data: []byte{0x81, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01}, expectedRes: 1, expectedRes64: 1},
+		{data: []byte{0x81, 0x00, 0x00, 0x01, 0x8C, 0x47, 0x1A, 0xFC, 0x00}, expectedRes: math.MaxUint32, expectedRes64: 1702000000000},
+	}
+
+	for k, data := range dp {
+		t.Run(fmt.Sprintf("Data provider #%d", k), func(t *testing.T) {
+			l, err := DecodeLength(bytes.NewReader(data.data))
+			r.NoError(err)
+			r.Equal(LengthType8Bytes, l.GetType())
+			r.Equal(data.expectedRes, l.GetLength())
+			r.Equal(data.expectedRes64, l.GetLength64())
+		})
+	}
+}
+
+func TestEncodeLength_GivenLength_SameLengthAfterDecode(t *testing.T) {
+	r := require.New(t)
+	type testData struct {
+		length        uint64
+		expectedBytes int
+	}
+	dp := []testData{
+		{length: 0, expectedBytes: 1},
+		{length: 63, expectedBytes: 1},
+		{length: 64, expectedBytes: 2},
+		{length: 16383, expectedBytes: 2},
+		{length: 16384, expectedBytes: 5},
+		{length: math.MaxUint32, expectedBytes: 5},
+		{length: math.MaxUint32 + 1, expectedBytes: 9},
+	}
+
+	for k, data := range dp {
+		t.Run(fmt.Sprintf("Data provider #%d", k), func(t *testing.T) {
+			encoded := EncodeLength(data.length)
+			r.Len(encoded, data.expectedBytes)
+			l, err := DecodeLength(bytes.NewReader(encoded))
+			r.NoError(err)
+			r.Equal(data.length, l.GetLength64())
+		})
+	}
+}
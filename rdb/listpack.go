@@ -0,0 +1,250 @@
+package rdb
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+)
+
+const (
+	listpackEnd        = 0xFF
+	listpackUnknownLen = 0xFFFF
+)
+
+var (
+	ErrListpackUnexpectedEncoding = errors.New("unexpected encoding of listpack entry")
+	ErrListpackBadLength          = errors.New("number of listpack entries is bad")
+)
+
+// Listpack decode listpack, it's used by RDB 10+ instead of ziplist
+// Docs https://github.com/antirez/listpack/blob/master/listpack.md
+type Listpack struct {
+	r ByteReader
+}
+
+func NewListpack(r ByteReader) *Listpack {
+	return &Listpack{r: r}
+}
+
+func (l *Listpack) Decode() (*Entries, error) {
+	header := make([]byte, 6)
+	if _, err := io.ReadFull(l.r, header); err != nil {
+		return nil, err
+	}
+	size := binary.LittleEndian.Uint32(header[0:4])
+	num := binary.LittleEndian.Uint16(header[4:6])
+	entries := NewEntries(size, 0, num)
+
+	for {
+		b, err := l.r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if b == listpackEnd {
+			break
+		}
+		entry, err := l.decodeEntry(b)
+		if err != nil {
+			return nil, err
+		}
+		entries.list = append(entries.list, entry)
+	}
+	// number of entries isn't known, when it's more than 65534
+	if num != listpackUnknownLen && int(num) != len(entries.list) {
+		return nil, ErrListpackBadLength
+	}
+
+	return entries, nil
+}
+
+func (l *Listpack) decodeEntry(b byte) (*Entry, error) {
+	var (
+		entry    *Entry
+		entryLen uint32
+		err      error
+	)
+	switch {
+	// 7 bit unsigned int
+	case b&0x80 == 0:
+		entry, entryLen = &Entry{t: EntryTypeInt, intVal: int64(b)}, 1
+	// string with 6 bit length
+	case b&0xC0 == 0x80:
+		entry, err = l.decodeString(uint32(b & 0x3F))
+		entryLen = 1 + uint32(b&0x3F)
+	// 13 bit signed int
+	case b&0xE0 == 0xC0:
+		next, nextErr := l.r.ReadByte()
+		if nextErr != nil {
+			return nil, nextErr
+		}
+		val := int64(b&0x1F)<<8 | int64(next)
+		if val >= 1<<12 {
+			val -= 1 << 13
+		}
+		entry, entryLen = &Entry{t: EntryTypeInt, intVal: val}, 2
+	// string with 12 bit length
+	case b&0xF0 == 0xE0:
+		next, nextErr := l.r.ReadByte()
+		if nextErr != nil {
+			return nil, nextErr
+		}
+		strLen := uint32(b&0x0F)<<8 | uint32(next)
+		entry, err = l.decodeString(strLen)
+		entryLen = 2 + strLen
+	// string with 32 bit length
+	case b == 0xF0:
+		bytes := make([]byte, 4)
+		if _, lenErr := io.ReadFull(l.r, bytes); lenErr != nil {
+			return nil, lenErr
+		}
+		strLen := binary.LittleEndian.Uint32(bytes)
+		entry, err = l.decodeString(strLen)
+		entryLen = 5 + strLen
+	case b == 0xF1:
+		entry, err = l.decodeInt(2)
+		entryLen = 3
+	case b == 0xF2:
+		entry, err = l.decodeInt(3)
+		entryLen = 4
+	case b == 0xF3:
+		entry, err = l.decodeInt(4)
+		entryLen = 5
+	case b == 0xF4:
+		entry, err = l.decodeInt(8)
+		entryLen = 9
+	default:
+		return nil, ErrListpackUnexpectedEncoding
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := l.skipBackLen(entryLen); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (l *Listpack) decodeString(length uint32) (*Entry, error) {
+	val := make([]byte, length)
+	if _, err := io.ReadFull(l.r, val); err != nil {
+		return nil, err
+	}
+	return &Entry{t: EntryTypeString, strVal: string(val)}, nil
+}
+
+// decodeInt decode little endian signed int with size from 2 to 8 bytes
+func (l *Listpack) decodeInt(size int) (*Entry, error) {
+	bytes := make([]byte, 8)
+	if _, err := io.ReadFull(l.r, bytes[:size]); err != nil {
+		return nil, err
+	}
+	// shift to top of int64 and back for sign extension
+	shift := uint(64 - size*8)
+	val := int64(binary.LittleEndian.Uint64(bytes)<<shift) >> shift
+	return &Entry{t: EntryTypeInt, intVal: val}, nil
+}
+
+// skipBackLen skip back length of entry, it's needed only for reverse traversal
+func (l *Listpack) skipBackLen(entryLen uint32) error {
+	var size int
+	switch {
+	case entryLen <= 127:
+		size = 1
+	case entryLen < 16383:
+		size = 2
+	case entryLen < 2097151:
+		size = 3
+	case entryLen < 268435455:
+		size = 4
+	default:
+		size = 5
+	}
+	_, err := io.ReadFull(l.r, make([]byte, size))
+	return err
+}
+
+// EncodeListpack encode elements to listpack, int elements are encoded as ints
+func EncodeListpack(elements []Bytes) []byte {
+	data := make([]byte, 6, 7+len(elements)*2)
+	for _, e := range elements {
+		entry := encodeListpackEntry(e)
+		data = append(data, entry...)
+		data = append(data, encodeListpackBackLen(uint64(len(entry)))...)
+	}
+	data = append(data, listpackEnd)
+	binary.LittleEndian.PutUint32(data[0:4], uint32(len(data)))
+	num := len(elements)
+	if num >= listpackUnknownLen {
+		num = listpackUnknownLen
+	}
+	binary.LittleEndian.PutUint16(data[4:6], uint16(num))
+	return data
+}
+
+func encodeListpackEntry(e Bytes) []byte {
+	if e.IsInt {
+		if v, err := e.Int(); err == nil {
+			return encodeListpackInt(v)
+		}
+	}
+	l := len(e.Val)
+	var res []byte
+	switch {
+	case l < 1<<6:
+		res = []byte{0x80 | byte(l)}
+	case l < 1<<12:
+		res = []byte{0xE0 | byte(l>>8), byte(l)}
+	default:
+		res = []byte{0xF0, 0, 0, 0, 0}
+		binary.LittleEndian.PutUint32(res[1:], uint32(l))
+	}
+	return append(res, e.Val...)
+}
+
+func encodeListpackInt(v int64) []byte {
+	var res []byte
+	switch {
+	case v >= 0 && v <= 127:
+		return []byte{byte(v)}
+	case v >= -4096 && v <= 4095:
+		u := uint16(v) & 0x1FFF
+		return []byte{0xC0 | byte(u>>8), byte(u)}
+	case v >= math.MinInt16 && v <= math.MaxInt16:
+		res = []byte{0xF1, 0, 0}
+	case v >= -1<<23 && v < 1<<23:
+		res = []byte{0xF2, 0, 0, 0}
+	case v >= math.MinInt32 && v <= math.MaxInt32:
+		res = []byte{0xF3, 0, 0, 0, 0}
+	default:
+		res = []byte{0xF4, 0, 0, 0, 0, 0, 0, 0, 0}
+	}
+	buf := make([]byte, 8)
+	binary.LittleEndian.PutUint64(buf, uint64(v))
+	copy(res[1:], buf)
+	return res
+}
+
+// encodeListpackBackLen encode length of entry for reverse traversal, every byte contains 7 bits
+func encodeListpackBackLen(l uint64) []byte {
+	var size int
+	switch {
+	case l <= 127:
+		return []byte{byte(l)}
+	case l < 16383:
+		size = 2
+	case l < 2097151:
+		size = 3
+	case l < 268435455:
+		size = 4
+	default:
+		size = 5
+	}
+	res := make([]byte, size)
+	for i := size - 1; i > 0; i-- {
+		res[i] = byte(l&127) | 128
+		l >>= 7
+	}
+	res[0] = byte(l)
+	return res
+}
@@ -0,0 +1,66 @@
+package rdb
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// buildListpack make listpack blob, total bytes isn't used by decoder
+func buildListpack(num uint16, entries ...byte) []byte {
+	data := []byte{
+		0x00, 0x00, 0x00, 0x00, // total bytes
+		byte(num), byte(num >> 8), // num elements
+	}
+	data = append(data, entries...)
+	return append(data, 0xFF)
+}
+
+func TestListpack_Decode_GivenAllEntryEncodings_Entries(t *testing.T) {
+	r := require.New(t)
+	data := buildListpack(9,
+		0x05, 0x01, // 7 bit uint
+		0x81, 'a', 0x02, // 6 bit string
+		0xE0, 0x02, 'b', 'c', 0x04, // 12 bit string
+		0xF0, 0x01, 0x00, 0x00, 0x00, 'd', 0x06, // 32 bit string
+		0xDF, 0xFF, 0x02, // 13 bit int
+		0xF1, 0x39, 0x30, 0x03, // int16
+		0xF2, 0xFE, 0xFF, 0xFF, 0x04, // int24
+		0xF3, 0xFF, 0xFF, 0xFF, 0xFF, 0x05, // int32
+		0xF4, 0x01, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x09, // int64
+	)
+
+	l, err := NewListpack(bytes.NewReader(data)).Decode()
+	r.NoError(err)
+	r.Equal([]string{"5", "a", "bc", "d", "-1", "12345", "-2", "-1", "4294967297"}, l.Strings())
+}
+
+func TestListpack_Decode_GivenUnknownNumOfEntries_Entries(t *testing.T) {
+	r := require.New(t)
+	data := buildListpack(0xFFFF, 0x01, 0x01, 0x02, 0x01)
+
+	l, err := NewListpack(bytes.NewReader(data)).Decode()
+	r.NoError(err)
+	r.Equal([]string{"1", "2"}, l.Strings())
+}
+
+func TestListpack_Decode_GivenBadNumOfEntries_Err(t *testing.T) {
+	r := require.New(t)
+	data := buildListpack(2, 0x01, 0x01)
+
+	l, err := NewListpack(bytes.NewReader(data)).Decode()
+	r.Error(err)
+	r.Nil(l)
+	r.Equal(ErrListpackBadLength, err)
+}
+
+func TestListpack_Decode_GivenUnexpectedEncoding_Err(t *testing.T) {
+	r := require.New(t)
+	data := buildListpack(1, 0xF5)
+
+	l, err := NewListpack(bytes.NewReader(data)).Decode()
+	r.Error(err)
+	r.Nil(l)
+	r.Equal(ErrListpackUnexpectedEncoding, err)
+}
@@ -0,0 +1,129 @@
+package rdb
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+)
+
+const (
+	moduleOpCodeEOF    = 0
+	moduleOpCodeSInt   = 1
+	moduleOpCodeUInt   = 2
+	moduleOpCodeFloat  = 3
+	moduleOpCodeDouble = 4
+	moduleOpCodeString = 5
+)
+
+// ModuleDecoder decode value of module saved in MODULE_2 format,
+// every value is prefixed by op code, so it can be decoded without module
+type ModuleDecoder struct {
+	r ByteReader
+}
+
+func NewModuleDecoder(r ByteReader) *ModuleDecoder {
+	return &ModuleDecoder{r: r}
+}
+
+func (d *ModuleDecoder) Decode() (*ModuleValue, error) {
+	id, err := d.decodeLen()
+	if err != nil {
+		return nil, err
+	}
+	res := &ModuleValue{ID: id}
+	res.Name, res.EncVer = moduleTypeName(id)
+	for {
+		opCode, err := d.decodeLen()
+		if err != nil {
+			return nil, err
+		}
+		switch opCode {
+		case moduleOpCodeEOF:
+			return res, nil
+		case moduleOpCodeSInt, moduleOpCodeUInt:
+			val, err := d.decodeLen()
+			if err != nil {
+				return nil, err
+			}
+			res.Data = append(res.Data, val)
+		case moduleOpCodeFloat:
+			bytes := make([]byte, 4)
+			if _, err := io.ReadFull(d.r, bytes); err != nil {
+				return nil, err
+			}
+			res.Data = append(res.Data, math.Float32frombits(binary.LittleEndian.Uint32(bytes)))
+		case moduleOpCodeDouble:
+			val, err := DecodeBinaryDouble(d.r)
+			if err != nil {
+				return nil, err
+			}
+			res.Data = append(res.Data, val)
+		case moduleOpCodeString:
+			val, err := NewStringDecoder(d.r).DecodeBinary()
+			if err != nil {
+				return nil, err
+			}
+			res.Data = append(res.Data, val)
+		default:
+			return nil, errors.New("unexpected op code of module value")
+		}
+	}
+}
+
+// DecodeAux decode aux data of module, it's saved like value with `when` flag before data
+func (d *ModuleDecoder) DecodeAux() (*ModuleAux, error) {
+	val, err := d.Decode()
+	if err != nil {
+		return nil, err
+	}
+	if len(val.Data) == 0 {
+		return nil, errors.New("module aux without when flag")
+	}
+	when, ok := val.Data[0].(uint64)
+	if !ok {
+		return nil, errors.New("unexpected type of when flag of module aux")
+	}
+	return &ModuleAux{ID: val.ID, Name: val.Name, EncVer: val.EncVer, When: when, Data: val.Data[1:]}, nil
+}
+
+func (d *ModuleDecoder) decodeLen() (uint64, error) {
+	length, err := DecodeLength(d.r)
+	if err != nil {
+		return 0, err
+	}
+	if length.IsEncodedString() {
+		return 0, errors.New("unexpected type of length for module value")
+	}
+	return length.GetLength64(), nil
+}
+
+// Skip read value of module without decoding
+func (d *ModuleDecoder) Skip() error {
+	if _, err := d.decodeLen(); err != nil {
+		return err
+	}
+	for {
+		opCode, err := d.decodeLen()
+		if err != nil {
+			return err
+		}
+		switch opCode {
+		case moduleOpCodeEOF:
+			return nil
+		case moduleOpCodeSInt, moduleOpCodeUInt:
+			_, err = d.decodeLen()
+		case moduleOpCodeFloat:
+			err = skipBytes(d.r, 4)
+		case moduleOpCodeDouble:
+			err = skipBytes(d.r, 8)
+		case moduleOpCodeString:
+			err = NewStringDecoder(d.r).Skip()
+		default:
+			return errors.New("unexpected op code of module value")
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package rdb
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// moduleTypeID make module type id from 9 chars name and encoding version
+func moduleTypeID(name string, encVer uint64) uint64 {
+	var id uint64
+	for _, c := range []byte(name) {
+		id = id<<6 | uint64(strings.IndexByte(moduleTypeNameCharSet, c))
+	}
+	return id<<10 | encVer
+}
+
+func TestModuleDecoder_Decode_GivenModule2Value_ModuleValue(t *testing.T) {
+	r := require.New(t)
+	data := EncodeLength(moduleTypeID("ReJSON-RL", 3))
+	data = append(data,
+		moduleOpCodeUInt, 0x05,
+		moduleOpCodeFloat, 0x00, 0x00, 0xC0, 0x3F, // 1.5
+		moduleOpCodeDouble, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x04, 0x40, // 2.5
+		moduleOpCodeString, 0x02, 'o', 'k',
+		moduleOpCodeEOF,
+	)
+
+	val, err := NewModuleDecoder(bytes.NewReader(data)).Decode()
+	r.NoError(err)
+	r.Equal("ReJSON-RL", val.Name)
+	r.Equal(uint64(3), val.EncVer)
+	r.Equal([]interface{}{uint64(5), float32(1.5), 2.5, Bytes{Val: []byte("ok")}}, val.Data)
+}
+
+func TestModuleDecoder_Decode_GivenUnexpectedOpCode_Err(t *testing.T) {
+	r := require.New(t)
+	data := append(EncodeLength(moduleTypeID("mymodule1", 0)), 0x06)
+
+	val, err := NewModuleDecoder(bytes.NewReader(data)).Decode()
+	r.Error(err)
+	r.Nil(val)
+}
@@ -1,6 +1,10 @@
 package rdb
 
 const (
+	OpCodeFUNCTION2 = 0xF5
+	OpCodeMODULEAUX = 0xF7
+	OpCodeIDLE = 0xF8
+	OpCodeFREQ = 0xF9
 	OpCodeAUX = 0xFA
 	OpCodeRESIZEDB = 0xFB
 	OpCodeEXPIRETIMEMS = 0xFC
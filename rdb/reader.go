@@ -1,28 +1,180 @@
 package rdb
 
 import (
-	"bufio"
+	"errors"
+	"fmt"
+	"io"
 )
 
+// ErrPayloadOverrun is returned when decoder needs more bytes than announced length of payload
+var ErrPayloadOverrun = errors.New("rdb payload is read beyond announced length")
+
+// PayloadUnderrunError is returned by CheckEnd when payload isn't read fully
+type PayloadUnderrunError struct {
+	Remaining uint64
+}
+
+func (e *PayloadUnderrunError) Error() string {
+	return fmt.Sprintf("rdb payload isn't read fully, %d bytes remained", e.Remaining)
+}
+
+// Reader limit reading of RDB payload by length announced by master, e.g. `$<len>` before RDB in SYNC response.
+// Reader doesn't read beyond payload, so reading of underlying reader continues exactly from the next byte.
 type Reader struct {
-	r   *bufio.Reader
+	r   ByteReader
 	len uint64
 }
 
-func NewReader(reader bufio.Reader, len uint64) *Reader {
+func NewReader(r ByteReader, len uint64) *Reader {
 	return &Reader{
-		r:   &reader,
+		r:   r,
 		len: len,
 	}
 }
 
 func (r *Reader) ReadByte() (byte, error) {
+	if r.len == 0 {
+		return 0, ErrPayloadOverrun
+	}
+	b, err := r.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
 	r.len--
-	return r.r.ReadByte()
+	return b, nil
 }
 
 func (r *Reader) Read(p []byte) (n int, err error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if r.len == 0 {
+		return 0, ErrPayloadOverrun
+	}
+	if uint64(len(p)) > r.len {
+		p = p[:r.len]
+	}
 	n, err = r.r.Read(p)
 	r.len -= uint64(n)
 	return
 }
+
+// Remaining return number of not read bytes of payload
+func (r *Reader) Remaining() uint64 {
+	return r.len
+}
+
+// CheckEnd return PayloadUnderrunError if payload isn't read fully
+func (r *Reader) CheckEnd() error {
+	if r.len != 0 {
+		return &PayloadUnderrunError{Remaining: r.len}
+	}
+	return nil
+}
+
+// PayloadReader is reader of RDB payload sent by master,
+// CheckEnd must be called after decoding to check payload is read fully
+type PayloadReader interface {
+	ByteReader
+	CheckEnd() error
+}
+
+// EOFMarkReader limit reading of RDB payload by mark, which is sent by master after payload in diskless replication,
+// e.g. `$EOF:<mark>` before RDB. Reader read ahead only length of mark, so after end of payload
+// reading of underlying reader continues exactly from the next byte after mark.
+type EOFMarkReader struct {
+	r      ByteReader
+	mark   []byte
+	buf    []byte
+	pos    int
+	filled bool
+	done   bool
+}
+
+func NewEOFMarkReader(r ByteReader, mark []byte) *EOFMarkReader {
+	return &EOFMarkReader{
+		r:    r,
+		mark: mark,
+		buf:  make([]byte, len(mark)),
+	}
+}
+
+func (r *EOFMarkReader) ReadByte() (byte, error) {
+	if err := r.fill(); err != nil {
+		return 0, err
+	}
+	if r.done {
+		return 0, ErrPayloadOverrun
+	}
+	next, err := r.r.ReadByte()
+	if err != nil {
+		if err == io.EOF {
+			return 0, io.ErrUnexpectedEOF
+		}
+		return 0, err
+	}
+	b := r.buf[r.pos]
+	r.buf[r.pos] = next
+	r.pos = (r.pos + 1) % len(r.buf)
+	if next == r.mark[len(r.mark)-1] {
+		r.done = r.isMark()
+	}
+	return b, nil
+}
+
+func (r *EOFMarkReader) Read(p []byte) (n int, err error) {
+	for n < len(p) {
+		if n > 0 && r.done {
+			return n, nil
+		}
+		p[n], err = r.ReadByte()
+		if err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// CheckEnd skip not read bytes of payload till mark and return PayloadUnderrunError if payload isn't read fully
+func (r *EOFMarkReader) CheckEnd() error {
+	var remaining uint64
+	for {
+		if err := r.fill(); err != nil {
+			return err
+		}
+		if r.done {
+			break
+		}
+		if _, err := r.ReadByte(); err != nil {
+			return err
+		}
+		remaining++
+	}
+	if remaining != 0 {
+		return &PayloadUnderrunError{Remaining: remaining}
+	}
+	return nil
+}
+
+// fill read first bytes, which can be mark if payload is empty
+func (r *EOFMarkReader) fill() error {
+	if r.filled {
+		return nil
+	}
+	if err := readFull(r.r, r.buf); err != nil {
+		return err
+	}
+	r.filled = true
+	r.done = r.isMark()
+	return nil
+}
+
+func (r *EOFMarkReader) isMark() bool {
+	for i := range r.mark {
+		if r.buf[(r.pos+i)%len(r.buf)] != r.mark[i] {
+			return false
+		}
+	}
+	return true
+}
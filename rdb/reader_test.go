@@ -0,0 +1,132 @@
+package rdb
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const readerTestTail = "*1\r\n$4\r\nPING\r\n"
+
+func readerTestPayload(t *testing.T) []byte {
+	buf := &bytes.Buffer{}
+	e := NewEncoder(buf)
+	e.RDBVersion("0009")
+	e.SelectDB(0)
+	for _, row := range encoderTestRows() {
+		e.Row(row)
+	}
+	e.End(nil)
+	require.NoError(t, e.Err())
+	return buf.Bytes()
+}
+
+func TestReader_GivenExactLength_StreamContinuesAtBoundary(t *testing.T) {
+	r := require.New(t)
+	payload := readerTestPayload(t)
+	br := bufio.NewReader(bytes.NewReader(append(payload, readerTestTail...)))
+
+	reader := NewReader(br, uint64(len(payload)))
+	r.NoError(NewDecoder(reader, &testConsumer{}).Decode())
+	r.NoError(reader.CheckEnd())
+	r.Equal(uint64(0), reader.Remaining())
+
+	line, err := br.ReadString('\n')
+	r.NoError(err)
+	r.Equal("*1\r\n", line)
+}
+
+func TestReader_GivenShortLength_ErrPayloadOverrun(t *testing.T) {
+	r := require.New(t)
+	payload := readerTestPayload(t)
+	br := bufio.NewReader(bytes.NewReader(append(payload, readerTestTail...)))
+
+	reader := NewReader(br, uint64(len(payload)-3))
+	r.Equal(ErrPayloadOverrun, NewDecoder(reader, &testConsumer{}).Decode())
+}
+
+func TestReader_GivenLongLength_PayloadUnderrunError(t *testing.T) {
+	r := require.New(t)
+	payload := readerTestPayload(t)
+	br := bufio.NewReader(bytes.NewReader(append(payload, readerTestTail...)))
+
+	reader := NewReader(br, uint64(len(payload)+2))
+	r.NoError(NewDecoder(reader, &testConsumer{}).Decode())
+	r.Equal(&PayloadUnderrunError{Remaining: 2}, reader.CheckEnd())
+}
+
+func TestReader_Read_GivenBufferBiggerThanPayload_ReadOnlyPayload(t *testing.T) {
+	r := require.New(t)
+	reader := NewReader(bytes.NewReader([]byte("abcdef")), 4)
+
+	p := make([]byte, 10)
+	n, err := reader.Read(p)
+	r.NoError(err)
+	r.Equal(4, n)
+	r.Equal("abcd", string(p[:n]))
+
+	_, err = reader.Read(p)
+	r.Equal(ErrPayloadOverrun, err)
+	_, err = reader.ReadByte()
+	r.Equal(ErrPayloadOverrun, err)
+}
+
+var readerTestMark = []byte("0123456789abcdef0123456789abcdef01234567")
+
+func TestEOFMarkReader_GivenPayloadWithMark_StreamContinuesAfterMark(t *testing.T) {
+	r := require.New(t)
+	payload := readerTestPayload(t)
+	data := append(append(append([]byte{}, payload...), readerTestMark...), readerTestTail...)
+	br := bufio.NewReader(bytes.NewReader(data))
+
+	reader := NewEOFMarkReader(br, readerTestMark)
+	c := &testConsumer{}
+	r.NoError(NewDecoder(reader, c).Decode())
+	r.Len(c.rows, len(encoderTestRows()))
+	r.NoError(reader.CheckEnd())
+
+	line, err := br.ReadString('\n')
+	r.NoError(err)
+	r.Equal("*1\r\n", line)
+}
+
+func TestEOFMarkReader_GivenNotReadPayload_PayloadUnderrunError(t *testing.T) {
+	r := require.New(t)
+	data := append(append([]byte("abc7"), readerTestMark...), readerTestTail...)
+	br := bufio.NewReader(bytes.NewReader(data))
+
+	reader := NewEOFMarkReader(br, readerTestMark)
+	b, err := reader.ReadByte()
+	r.NoError(err)
+	r.Equal(byte('a'), b)
+	r.Equal(&PayloadUnderrunError{Remaining: 3}, reader.CheckEnd())
+
+	line, err := br.ReadString('\n')
+	r.NoError(err)
+	r.Equal("*1\r\n", line)
+}
+
+func TestEOFMarkReader_Read_GivenBufferBiggerThanPayload_ReadOnlyPayload(t *testing.T) {
+	r := require.New(t)
+	data := append([]byte("abcd"), readerTestMark...)
+	reader := NewEOFMarkReader(bytes.NewReader(data), readerTestMark)
+
+	p := make([]byte, 100)
+	n, err := reader.Read(p)
+	r.NoError(err)
+	r.Equal("abcd", string(p[:n]))
+
+	_, err = reader.Read(p)
+	r.Equal(ErrPayloadOverrun, err)
+	r.NoError(reader.CheckEnd())
+}
+
+func TestEOFMarkReader_GivenNoMark_ErrUnexpectedEOF(t *testing.T) {
+	r := require.New(t)
+	reader := NewEOFMarkReader(bytes.NewReader(append([]byte("abcd"), readerTestMark[:39]...)), readerTestMark)
+
+	r.Equal(io.ErrUnexpectedEOF, reader.CheckEnd())
+}
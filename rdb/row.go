@@ -5,11 +5,9 @@ import (
 	"bytes"
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"io"
-	"log"
 	"time"
-
-	abc "github.com/zhuyie/golzf"
 )
 
 type ValueType byte
@@ -18,35 +16,218 @@ const (
 	OpCodeKeyExpirySecond       = 0xFD
 	OpCodeKeyExpiryMilliseconds = 0xFC
 
-	ValueTypeString           ValueType = 0
-	ValueTypeList             ValueType = 1
-	ValueTypeSet              ValueType = 2
-	ValueTypeSortedSet        ValueType = 3
-	ValueTypeHash             ValueType = 4
-	ValueTypeZipmap           ValueType = 9
-	ValueTypeZiplist          ValueType = 10
-	ValueTypeIntset           ValueType = 11
-	ValueTypeSortedSetZiplist ValueType = 12
-	ValueTypeHashmapZiplist   ValueType = 13
-	ValueTypeListQuicklist    ValueType = 14
+	ValueTypeString            ValueType = 0
+	ValueTypeList              ValueType = 1
+	ValueTypeSet               ValueType = 2
+	ValueTypeSortedSet         ValueType = 3
+	ValueTypeHash              ValueType = 4
+	ValueTypeSortedSet2        ValueType = 5
+	ValueTypeModule2           ValueType = 7
+	ValueTypeZipmap            ValueType = 9
+	ValueTypeZiplist           ValueType = 10
+	ValueTypeIntset            ValueType = 11
+	ValueTypeSortedSetZiplist  ValueType = 12
+	ValueTypeHashmapZiplist    ValueType = 13
+	ValueTypeListQuicklist     ValueType = 14
+	ValueTypeStreamListpacks   ValueType = 15
+	ValueTypeHashListpack      ValueType = 16
+	ValueTypeSortedSetListpack ValueType = 17
+	ValueTypeListQuicklist2    ValueType = 18
+	ValueTypeStreamListpacks2  ValueType = 19
+	ValueTypeSetListpack       ValueType = 20
+	ValueTypeStreamListpacks3  ValueType = 21
+
+	quicklistNodePlain  = 1
+	quicklistNodePacked = 2
 )
 
 type Row struct {
-	Expiry       *time.Time
-	Key          string
-	Type         ValueType
-	ValString    string
-	ValStringSet []string
-	ValIntSet    []int
-	ValMap       map[string]string
+	Expiry *time.Time
+	Key    string
+	// RawKey is binary safe key, which remembers int encoding
+	RawKey Bytes
+	// Type is encoding of value in RDB, logical type is Value.Kind()
+	Type  ValueType
+	Value Value
+	// Expired is set by decoder with ExpiredKeysFlag, when key is expired by clock of decoder
+	Expired bool
+	// Idle is LRU idle time of key in seconds, it's saved with maxmemory-policy allkeys-lru or volatile-lru
+	Idle *uint64
+	// Freq is LFU counter of key, it's saved with maxmemory-policy allkeys-lfu or volatile-lfu
+	Freq *uint8
 }
 
 func (r *Row) hasTTL() bool {
 	return r.Expiry != nil
 }
 
+// ExpiryUnixMilli return expiry as absolute unix time in milliseconds, like it's saved in RDB
+func (r *Row) ExpiryUnixMilli() (int64, bool) {
+	if r.Expiry == nil {
+		return 0, false
+	}
+	return unixMilli(*r.Expiry), true
+}
+
+// Kind return logical type of value
+func (r *Row) Kind() Kind {
+	if r.Value == nil {
+		return 0
+	}
+	return r.Value.Kind()
+}
+
+func (r *Row) AsString() (*StringValue, bool) {
+	v, ok := r.Value.(*StringValue)
+	return v, ok
+}
+
+func (r *Row) AsList() (*ListValue, bool) {
+	v, ok := r.Value.(*ListValue)
+	return v, ok
+}
+
+func (r *Row) AsSet() (*SetValue, bool) {
+	v, ok := r.Value.(*SetValue)
+	return v, ok
+}
+
+func (r *Row) AsSortedSet() (*SortedSetValue, bool) {
+	v, ok := r.Value.(*SortedSetValue)
+	return v, ok
+}
+
+func (r *Row) AsHash() (*HashValue, bool) {
+	v, ok := r.Value.(*HashValue)
+	return v, ok
+}
+
+func (r *Row) AsStream() (*Stream, bool) {
+	v, ok := r.Value.(*Stream)
+	return v, ok
+}
+
+func (r *Row) AsModule() (*ModuleValue, bool) {
+	v, ok := r.Value.(*ModuleValue)
+	return v, ok
+}
+
+// Kind return logical type of value with this encoding
+func (t ValueType) Kind() Kind {
+	switch t {
+	case ValueTypeString:
+		return KindString
+	case ValueTypeList, ValueTypeZiplist, ValueTypeListQuicklist, ValueTypeListQuicklist2:
+		return KindList
+	case ValueTypeSet, ValueTypeIntset, ValueTypeSetListpack:
+		return KindSet
+	case ValueTypeSortedSet, ValueTypeSortedSet2, ValueTypeSortedSetZiplist, ValueTypeSortedSetListpack:
+		return KindSortedSet
+	case ValueTypeHash, ValueTypeZipmap, ValueTypeHashmapZiplist, ValueTypeHashListpack:
+		return KindHash
+	case ValueTypeStreamListpacks, ValueTypeStreamListpacks2, ValueTypeStreamListpacks3:
+		return KindStream
+	case ValueTypeModule2:
+		return KindModule
+	}
+	return 0
+}
+
+// String return name of encoding like in RDB type constants of redis
+func (t ValueType) String() string {
+	switch t {
+	case ValueTypeString:
+		return "string"
+	case ValueTypeList:
+		return "list"
+	case ValueTypeSet:
+		return "set"
+	case ValueTypeSortedSet:
+		return "zset"
+	case ValueTypeHash:
+		return "hash"
+	case ValueTypeSortedSet2:
+		return "zset_2"
+	case ValueTypeModule2:
+		return "module_2"
+	case ValueTypeZipmap:
+		return "hash_zipmap"
+	case ValueTypeZiplist:
+		return "list_ziplist"
+	case ValueTypeIntset:
+		return "set_intset"
+	case ValueTypeSortedSetZiplist:
+		return "zset_ziplist"
+	case ValueTypeHashmapZiplist:
+		return "hash_ziplist"
+	case ValueTypeListQuicklist:
+		return "list_quicklist"
+	case ValueTypeStreamListpacks:
+		return "stream_listpacks"
+	case ValueTypeHashListpack:
+		return "hash_listpack"
+	case ValueTypeSortedSetListpack:
+		return "zset_listpack"
+	case ValueTypeListQuicklist2:
+		return "list_quicklist_2"
+	case ValueTypeStreamListpacks2:
+		return "stream_listpacks_2"
+	case ValueTypeSetListpack:
+		return "set_listpack"
+	case ValueTypeStreamListpacks3:
+		return "stream_listpacks_3"
+	}
+	return "unknown"
+}
+
+func (t ValueType) isKnown() bool {
+	switch t {
+	case ValueTypeString,
+		ValueTypeList,
+		ValueTypeSet,
+		ValueTypeSortedSet,
+		ValueTypeHash,
+		ValueTypeSortedSet2,
+		ValueTypeModule2,
+		ValueTypeZipmap,
+		ValueTypeZiplist,
+		ValueTypeIntset,
+		ValueTypeSortedSetZiplist,
+		ValueTypeHashmapZiplist,
+		ValueTypeListQuicklist,
+		ValueTypeHashListpack,
+		ValueTypeSortedSetListpack,
+		ValueTypeListQuicklist2,
+		ValueTypeSetListpack,
+		ValueTypeStreamListpacks,
+		ValueTypeStreamListpacks2,
+		ValueTypeStreamListpacks3:
+		return true
+	}
+	return false
+}
+
 type RowDecoder struct {
-	r ByteReader
+	r         ByteReader
+	chunkSize int
+}
+
+func NewRowDecoder(r ByteReader) *RowDecoder {
+	return &RowDecoder{r: r}
+}
+
+// NewChunkedRowDecoder return decoder, which pass collections to ElementConsumer by chunks with chunkSize elements
+func NewChunkedRowDecoder(r ByteReader, chunkSize int) *RowDecoder {
+	return &RowDecoder{r: r, chunkSize: chunkSize}
+}
+
+// IsRowStart return true if byte can be first byte of row: expiry, idle, freq op code or value type
+func IsRowStart(b byte) bool {
+	switch b {
+	case OpCodeKeyExpirySecond, OpCodeKeyExpiryMilliseconds, OpCodeIDLE, OpCodeFREQ:
+		return true
+	}
+	return ValueType(b).isKnown()
 }
 
 func (d *RowDecoder) Decode() (*Row, error) {
@@ -54,195 +235,392 @@ func (d *RowDecoder) Decode() (*Row, error) {
 	if err != nil {
 		return nil, err
 	}
-	row := &Row{}
-	switch b {
-	case OpCodeKeyExpirySecond:
-		bytes := make([]byte, 4)
-		if _, err := io.ReadFull(d.r, bytes); err != nil {
-			return nil, err
-		}
-		row.Expiry = new(time.Time)
-		*row.Expiry = time.Unix(int64(binary.LittleEndian.Uint32(bytes)), 0)
+	return d.DecodeWithFirstByte(b)
+}
 
-	case OpCodeKeyExpiryMilliseconds:
-		bytes := make([]byte, 8)
-		if _, err := io.ReadFull(d.r, bytes); err != nil {
-			return nil, err
-		}
-		row.Expiry = new(time.Time)
-		*row.Expiry = time.Unix(int64(binary.LittleEndian.Uint64(bytes))/1000, int64(binary.BigEndian.Uint64(bytes))%1000)
+// DecodeWithFirstByte decode row, when first byte(expiry, idle, freq op code or value type) already read from reader
+func (d *RowDecoder) DecodeWithFirstByte(b byte) (*Row, error) {
+	row, err := d.decodeHeader(b)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.decodeRowValue(row); err != nil {
+		return nil, err
+	}
+	return row, nil
+}
 
+// DecodeElements decode row, when first byte already read from reader, and pass it to consumer by chunks.
+// Row passed to consumer doesn't contain value.
+func (d *RowDecoder) DecodeElements(b byte, c ElementConsumer) error {
+	row, err := d.decodeHeader(b)
+	if err != nil {
+		return err
 	}
+	return d.decodeRowElements(row, c)
+}
+
+// decodeRowValue decode value of row with decoded header, value is collected by one chunk
+func (d *RowDecoder) decodeRowValue(row *Row) error {
+	return d.decodeValue(row.Type, 0, func(chunk Value) {
+		row.Value = AppendValue(row.Value, chunk)
+	})
+}
 
+// decodeRowElements decode value of row with decoded header and pass it to consumer by chunks
+func (d *RowDecoder) decodeRowElements(row *Row, c ElementConsumer) error {
+	c.BeginRow(row)
+	err := d.decodeValue(row.Type, d.chunkSize, func(chunk Value) {
+		c.Elements(row, chunk)
+	})
+	if err != nil {
+		return err
+	}
+	c.EndRow(row)
+	return nil
+}
+
+// decodeHeader decode expiry, idle or freq, value type and key of row
+func (d *RowDecoder) decodeHeader(b byte) (*Row, error) {
+	var err error
+	row := &Row{}
 	valType := b
-	if row.hasTTL() {
+prefix:
+	for {
+		switch valType {
+		case OpCodeKeyExpirySecond:
+			bytes := make([]byte, 4)
+			if _, err := io.ReadFull(d.r, bytes); err != nil {
+				return nil, err
+			}
+			row.Expiry = new(time.Time)
+			*row.Expiry = time.Unix(int64(binary.LittleEndian.Uint32(bytes)), 0)
+		case OpCodeKeyExpiryMilliseconds:
+			expiry, err := decodeMillisecondTime(d.r)
+			if err != nil {
+				return nil, err
+			}
+			row.Expiry = &expiry
+		case OpCodeIDLE:
+			idle, err := DecodeLength(d.r)
+			if err != nil {
+				return nil, err
+			}
+			v := idle.GetLength64()
+			row.Idle = &v
+		case OpCodeFREQ:
+			freq, err := d.r.ReadByte()
+			if err != nil {
+				return nil, err
+			}
+			row.Freq = &freq
+		default:
+			break prefix
+		}
 		valType, err = d.r.ReadByte()
 		if err != nil {
 			return nil, err
 		}
 	}
 	row.Type = ValueType(valType)
+	if !row.Type.isKnown() {
+		return nil, fmt.Errorf("unexpected value type code %d", row.Type)
+	}
 
-	// lpd := NewLengthPrefixedStringDecoder(d.r)
-	// key, err := lpd.Decode()
-	// if err != nil {
-	// 	return nil, err
-	// }
-	keyLen, err := DecodeLength(d.r)
+	rawKey, err := NewStringDecoder(d.r).DecodeBinary()
 	if err != nil {
 		return nil, err
 	}
-	if keyLen.IsEncodedString() {
-		data := make([]byte, keyLen.GetLength())
-		if _, err := io.ReadFull(d.r, data); err != nil {
-			return nil, err
-		}
+	row.RawKey = rawKey
+	row.Key = rawKey.String()
+	return row, nil
+}
 
-		res := make([]byte, keyLen.GetVerifyLength())
-		abc.Decompress(data, res)
-		row.Key = string(res)
-		// if err := keyLen.CheckVerifyLength(uint32(len(k))); err != nil {
-		// 	return nil, err
-		// }
-	} else {
-		row.Key, err = DecodeLengthPrefixedString(d.r, keyLen.GetLength())
+// decodeValue decode value and pass it to emit, collections are passed by chunks with chunkSize elements
+func (d *RowDecoder) decodeValue(t ValueType, chunkSize int, emit func(chunk Value)) error {
+	sink := newValueSink(t.Kind(), chunkSize, emit)
+	switch t {
+	case ValueTypeString:
+		val, err := NewStringDecoder(d.r).DecodeBinary()
 		if err != nil {
-			return nil, err
+			return err
 		}
-	}
-
-	switch row.Type {
-	case ValueTypeString:
-		var v string
-
-		valLen, err := DecodeLength(d.r)
+		emit(&StringValue{Val: val})
+		return nil
+	case ValueTypeList, ValueTypeSet:
+		length, err := d.decodeCount()
 		if err != nil {
-			return nil, err
+			return err
 		}
-		if valLen.IsEncodedString() {
-			data := make([]byte, valLen.GetLength())
-			if _, err := d.r.Read(data); err != nil {
-				return nil, err
-			}
-			res := make([]byte, valLen.GetVerifyLength())
-			abc.Decompress(data, res)
-			v = string(res)
-			// if err := valLen.CheckVerifyLength(uint32(len(v))); err != nil {
-			// 	return nil, err
-			// }
-		} else {
-			v, err = DecodeLengthPrefixedString(d.r, valLen.GetLength())
+		sink.grow(length)
+		for i := uint64(0); i < length; i++ {
+			val, err := NewStringDecoder(d.r).DecodeBinary()
 			if err != nil {
-				return nil, err
+				return err
 			}
+			sink.add(val)
 		}
-		row.ValString = v
-	case ValueTypeList:
-		length, err := DecodeLength(d.r)
-		if err != nil {
-			return nil, err
+	case ValueTypeSortedSet, ValueTypeSortedSet2:
+		decodeScore := DecodeDouble
+		if t == ValueTypeSortedSet2 {
+			decodeScore = DecodeBinaryDouble
 		}
-		if length.IsEncodedString() {
-			return nil, errors.New("unexpected ytpe of length for list")
+		length, err := d.decodeCount()
+		if err != nil {
+			return err
 		}
-		row.ValStringSet = make([]string, 0, length.GetLength())
-		for i := uint32(0); i < length.GetLength(); i++ {
-			val, err := NewLengthPrefixedStringDecoder(d.r).Decode()
+		sink.grow(length)
+		for i := uint64(0); i < length; i++ {
+			member, err := NewStringDecoder(d.r).DecodeBinary()
 			if err != nil {
-				return nil, err
+				return err
 			}
-			row.ValStringSet = append(row.ValStringSet, val)
+			score, err := decodeScore(d.r)
+			if err != nil {
+				return err
+			}
+			sink.addEntry(SortedSetEntry{Member: member, Score: score})
 		}
-	case ValueTypeSet:
-	case ValueTypeSortedSet:
 	case ValueTypeHash:
-		row.ValMap = make(map[string]string)
-		length, err := DecodeLength(d.r)
+		length, err := d.decodeCount()
 		if err != nil {
-			return nil, err
+			return err
 		}
-		lengthVal := length.GetLength()
-		for lengthVal > 0 {
-			lengthVal--
-
-			var k, v string
-
-			keyLen, err := DecodeLength(d.r)
+		sink.grow(length)
+		for i := uint64(0); i < length; i++ {
+			field, err := NewStringDecoder(d.r).DecodeBinary()
 			if err != nil {
-				return nil, err
+				return err
 			}
-			if keyLen.IsEncodedString() {
-				data := make([]byte, keyLen.GetLength())
-				if _, err := d.r.Read(data); err != nil {
-					return nil, err
-				}
-
-				res := make([]byte, keyLen.GetVerifyLength())
-				abc.Decompress(data, res)
-				k = string(res)
-				// if err := keyLen.CheckVerifyLength(uint32(len(k))); err != nil {
-				// 	return nil, err
-				// }
-			} else {
-				k, err = DecodeLengthPrefixedString(d.r, keyLen.GetLength())
-				if err != nil {
-					return nil, err
-				}
+			val, err := NewStringDecoder(d.r).DecodeBinary()
+			if err != nil {
+				return err
 			}
-
-			valLen, err := DecodeLength(d.r)
+			sink.addPair(field, val)
+		}
+	case ValueTypeZipmap:
+		pairs, err := NewZipMap(d.r).DecodePairs()
+		if err != nil {
+			return err
+		}
+		if err := sink.addPairs(pairs); err != nil {
+			return err
+		}
+	case ValueTypeZiplist, ValueTypeSetListpack:
+		l, err := d.decodeZiplistOrListpack(t == ValueTypeSetListpack)
+		if err != nil {
+			return err
+		}
+		for _, entry := range l.list {
+			sink.add(entry.Bytes())
+		}
+	case ValueTypeIntset:
+		data, err := NewStringDecoder(d.r).DecodeToBytes()
+		if err != nil {
+			return err
+		}
+		ints, err := DecodeIntset(data)
+		if err != nil {
+			return err
+		}
+		sink.grow(uint64(len(ints)))
+		for _, v := range ints {
+			sink.add(NewIntBytes(int64(v)))
+		}
+	case ValueTypeSortedSetZiplist, ValueTypeSortedSetListpack:
+		l, err := d.decodeZiplistOrListpack(t == ValueTypeSortedSetListpack)
+		if err != nil {
+			return err
+		}
+		if err := sink.addEntries(l); err != nil {
+			return err
+		}
+	case ValueTypeHashmapZiplist, ValueTypeHashListpack:
+		l, err := d.decodeZiplistOrListpack(t == ValueTypeHashListpack)
+		if err != nil {
+			return err
+		}
+		if err := sink.addPairs(l.Bytes()); err != nil {
+			return err
+		}
+	case ValueTypeListQuicklist:
+		length, err := d.decodeCount()
+		if err != nil {
+			return err
+		}
+		// every node of quicklist is ziplist
+		for i := uint64(0); i < length; i++ {
+			l, err := d.decodeZiplist()
 			if err != nil {
-				return nil, err
+				return err
+			}
+			for _, entry := range l.list {
+				sink.add(entry.Bytes())
+			}
+		}
+	case ValueTypeListQuicklist2:
+		length, err := d.decodeCount()
+		if err != nil {
+			return err
+		}
+		for i := uint64(0); i < length; i++ {
+			container, err := DecodeLength(d.r)
+			if err != nil {
+				return err
 			}
-			if valLen.IsEncodedString() {
-				data := make([]byte, valLen.GetLength())
-				n, err := io.ReadFull(d.r, data)
+			switch container.GetLength() {
+			// plain node contains one big element
+			case quicklistNodePlain:
+				element, err := NewStringDecoder(d.r).DecodeBinary()
 				if err != nil {
-					return nil, err
+					return err
 				}
-				_ = n
-				res := make([]byte, valLen.GetVerifyLength())
-				abc.Decompress(data, res)
-				v = string(res)
-				// if err := valLen.CheckVerifyLength(uint32(len(v))); err != nil {
-				// 	return nil, err
-				// }
-			} else {
-				v, err = DecodeLengthPrefixedString(d.r, valLen.GetLength())
+				sink.add(element)
+			case quicklistNodePacked:
+				l, err := d.decodeListpack()
 				if err != nil {
-					return nil, err
+					return err
 				}
+				for _, entry := range l.list {
+					sink.add(entry.Bytes())
+				}
+			default:
+				return errors.New("unexpected container of quicklist node")
 			}
-
-			row.ValMap[k] = v
 		}
-	case ValueTypeZipmap:
-	case ValueTypeZiplist:
-	case ValueTypeIntset:
-	case ValueTypeSortedSetZiplist:
-	case ValueTypeHashmapZiplist:
-		data, err := NewStringDecoder(d.r).DecodeToBytes()
+	case ValueTypeStreamListpacks, ValueTypeStreamListpacks2, ValueTypeStreamListpacks3:
+		stream, err := NewStreamDecoder(d.r, t).Decode()
 		if err != nil {
-			return nil, err
+			return err
 		}
-		l, err := NewZiplist(bufio.NewReader(bytes.NewBuffer(data))).Decode()
+		emit(stream)
+		return nil
+	case ValueTypeModule2:
+		module, err := NewModuleDecoder(d.r).Decode()
 		if err != nil {
-			if err != ErrUnexpectedEndByte {
-				return nil, err
+			return err
+		}
+		emit(module)
+		return nil
+	default:
+		return fmt.Errorf("unexpected value type code %d", t)
+	}
+	sink.close()
+	return nil
+}
+
+// skipValue read value without decoding, strings aren't allocated and decompressed
+func (d *RowDecoder) skipValue(t ValueType) error {
+	switch t {
+	case ValueTypeString,
+		ValueTypeZipmap,
+		ValueTypeZiplist,
+		ValueTypeIntset,
+		ValueTypeSortedSetZiplist,
+		ValueTypeHashmapZiplist,
+		ValueTypeHashListpack,
+		ValueTypeSortedSetListpack,
+		ValueTypeSetListpack:
+		// compact encodings are saved as one string
+		return NewStringDecoder(d.r).Skip()
+	case ValueTypeList, ValueTypeSet, ValueTypeHash, ValueTypeListQuicklist:
+		length, err := d.decodeCount()
+		if err != nil {
+			return err
+		}
+		if t == ValueTypeHash {
+			length *= 2
+		}
+		for i := uint64(0); i < length; i++ {
+			if err := NewStringDecoder(d.r).Skip(); err != nil {
+				return err
 			}
-			log.Println(data)
-			log.Println(string(data))
 		}
-		res := make(map[string]string)
-		for i := 0; i < len(l.list); i += 2 {
-			res[l.list[i].String()] = l.list[i+1].String()
+	case ValueTypeSortedSet, ValueTypeSortedSet2:
+		length, err := d.decodeCount()
+		if err != nil {
+			return err
 		}
-
-		row.ValMap = res
-	case ValueTypeListQuicklist:
+		for i := uint64(0); i < length; i++ {
+			if err := NewStringDecoder(d.r).Skip(); err != nil {
+				return err
+			}
+			if err := d.skipScore(t); err != nil {
+				return err
+			}
+		}
+	case ValueTypeListQuicklist2:
+		length, err := d.decodeCount()
+		if err != nil {
+			return err
+		}
+		for i := uint64(0); i < length; i++ {
+			if _, err := DecodeLength(d.r); err != nil {
+				return err
+			}
+			if err := NewStringDecoder(d.r).Skip(); err != nil {
+				return err
+			}
+		}
+	case ValueTypeStreamListpacks, ValueTypeStreamListpacks2, ValueTypeStreamListpacks3:
+		return NewStreamDecoder(d.r, t).Skip()
+	case ValueTypeModule2:
+		return NewModuleDecoder(d.r).Skip()
 	default:
-		log.Println(row.Type)
-		return nil, errors.New("unexpected value type code")
+		return fmt.Errorf("unexpected value type code %d", t)
 	}
-	return row, nil
+	return nil
+}
+
+// skipScore skip binary double of ZSET_2 or double saved as string with length prefix
+func (d *RowDecoder) skipScore(t ValueType) error {
+	if t == ValueTypeSortedSet2 {
+		return skipBytes(d.r, 8)
+	}
+	l, err := d.r.ReadByte()
+	if err != nil {
+		return err
+	}
+	// NaN and infinities are saved without string
+	if l >= 253 {
+		return nil
+	}
+	return skipBytes(d.r, uint64(l))
+}
+
+// decodeCount decode number of elements of collection
+func (d *RowDecoder) decodeCount() (uint64, error) {
+	length, err := DecodeLength(d.r)
+	if err != nil {
+		return 0, err
+	}
+	if length.IsEncodedString() {
+		return 0, errors.New("unexpected type of length for collection")
+	}
+	return length.GetLength64(), nil
+}
+
+func (d *RowDecoder) decodeZiplistOrListpack(isListpack bool) (*Entries, error) {
+	if isListpack {
+		return d.decodeListpack()
+	}
+	return d.decodeZiplist()
+}
+
+// decodeZiplist read ziplist blob as string and decode entries from it
+func (d *RowDecoder) decodeZiplist() (*Entries, error) {
+	data, err := NewStringDecoder(d.r).DecodeToBytes()
+	if err != nil {
+		return nil, err
+	}
+	return NewZiplist(bufio.NewReader(bytes.NewBuffer(data))).Decode()
+}
+
+// decodeListpack read listpack blob as string and decode entries from it
+func (d *RowDecoder) decodeListpack() (*Entries, error) {
+	data, err := NewStringDecoder(d.r).DecodeToBytes()
+	if err != nil {
+		return nil, err
+	}
+	return NewListpack(bufio.NewReader(bytes.NewBuffer(data))).Decode()
 }
@@ -0,0 +1,327 @@
+package rdb
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRowDecoder_Decode_GivenSet_Members(t *testing.T) {
+	r := require.New(t)
+	data := []byte{
+		byte(ValueTypeSet),
+		0x03, 's', 'e', 't', // key
+		0x03,                // number of members
+		0x03, 'o', 'n', 'e', // plain string
+		0xC0, 0x02, // 8 bit int
+		0xC1, 0x39, 0x30, // 16 bit int
+	}
+
+	row, err := NewRowDecoder(bytes.NewReader(data)).Decode()
+	r.NoError(err)
+	r.Equal(ValueTypeSet, row.Type)
+	r.Equal("set", row.Key)
+	set, ok := row.AsSet()
+	r.True(ok)
+	r.Equal([]string{"one", "2", "12345"}, set.Strings())
+}
+
+func TestRowDecoder_Decode_GivenSortedSet_MembersWithScores(t *testing.T) {
+	r := require.New(t)
+	data := []byte{
+		byte(ValueTypeSortedSet),
+		0x01, 'z', // key
+		0x03,      // number of members
+		0x01, 'a', // member
+		0x03, '1', '.', '5', // score
+		0x01, 'b',
+		0xFE, // +inf
+		0x01, 'c',
+		0xFF, // -inf
+	}
+
+	row, err := NewRowDecoder(bytes.NewReader(data)).Decode()
+	r.NoError(err)
+	r.Equal(ValueTypeSortedSet, row.Type)
+	zset, ok := row.AsSortedSet()
+	r.True(ok)
+	r.Equal(map[string]float64{"a": 1.5, "b": math.Inf(1), "c": math.Inf(-1)}, zset.Scores())
+}
+
+func TestRowDecoder_Decode_GivenSortedSet2_MembersWithBinaryScores(t *testing.T) {
+	r := require.New(t)
+	data := []byte{
+		byte(ValueTypeSortedSet2),
+		0x01, 'z', // key
+		0x02,      // number of members
+		0x01, 'a', // member
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xF8, 0x3F, // 1.5
+		0x01, 'b',
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xC0, // -2
+	}
+
+	row, err := NewRowDecoder(bytes.NewReader(data)).Decode()
+	r.NoError(err)
+	r.Equal(ValueTypeSortedSet2, row.Type)
+	r.Equal(&SortedSetValue{Entries: []SortedSetEntry{
+		{Member: Bytes{Val: []byte("a")}, Score: 1.5},
+		{Member: Bytes{Val: []byte("b")}, Score: -2},
+	}}, row.Value)
+}
+
+func TestRowDecoder_Decode_GivenIntset_Ints(t *testing.T) {
+	r := require.New(t)
+	data := []byte{
+		byte(ValueTypeIntset),
+		0x01, 'i', // key
+		0x0C,                   // length of intset blob
+		0x02, 0x00, 0x00, 0x00, // encoding
+		0x02, 0x00, 0x00, 0x00, // length
+		0x01, 0x00, 0x02, 0x00,
+	}
+
+	row, err := NewRowDecoder(bytes.NewReader(data)).Decode()
+	r.NoError(err)
+	r.Equal(ValueTypeIntset, row.Type)
+	r.Equal(&SetValue{Members: []Bytes{NewIntBytes(1), NewIntBytes(2)}}, row.Value)
+}
+
+func TestRowDecoder_Decode_GivenZipmap_Map(t *testing.T) {
+	r := require.New(t)
+	data := []byte{
+		byte(ValueTypeZipmap),
+		0x01, 'h', // key
+		0x07,      // length of zipmap blob
+		0x01,      // zmlen
+		0x01, 'k', // key
+		0x01, 0x00, 'v', // val
+		0xFF, // end
+	}
+
+	row, err := NewRowDecoder(bytes.NewReader(data)).Decode()
+	r.NoError(err)
+	r.Equal(ValueTypeZipmap, row.Type)
+	r.Equal(KindHash, row.Kind())
+	r.Equal(map[string]string{"k": "v"}, row.Value.(*HashValue).Map())
+}
+
+func TestRowDecoder_Decode_GivenListZiplist_List(t *testing.T) {
+	r := require.New(t)
+	zl := buildZiplist(2, 0x00, 0x01, 'a', 0x03, 0xF3)
+	data := append([]byte{byte(ValueTypeZiplist), 0x01, 'l', byte(len(zl))}, zl...)
+
+	row, err := NewRowDecoder(bytes.NewReader(data)).Decode()
+	r.NoError(err)
+	r.Equal(ValueTypeZiplist, row.Type)
+	r.Equal([]string{"a", "2"}, row.Value.(*ListValue).Strings())
+}
+
+func TestRowDecoder_Decode_GivenSortedSetZiplist_MembersWithScores(t *testing.T) {
+	r := require.New(t)
+	zl := buildZiplist(4,
+		0x00, 0x01, 'a', // member
+		0x03, 0x03, '1', '.', '5', // score
+		0x05, 0x01, 'b', // member
+		0x03, 0xF3, // score
+	)
+	data := append([]byte{byte(ValueTypeSortedSetZiplist), 0x01, 'z', byte(len(zl))}, zl...)
+
+	row, err := NewRowDecoder(bytes.NewReader(data)).Decode()
+	r.NoError(err)
+	r.Equal(ValueTypeSortedSetZiplist, row.Type)
+	r.Equal(map[string]float64{"a": 1.5, "b": 2}, row.Value.(*SortedSetValue).Scores())
+}
+
+func TestRowDecoder_Decode_GivenSortedSetZiplistWithOddEntries_Err(t *testing.T) {
+	r := require.New(t)
+	zl := buildZiplist(1, 0x00, 0x01, 'a')
+	data := append([]byte{byte(ValueTypeSortedSetZiplist), 0x01, 'z', byte(len(zl))}, zl...)
+
+	row, err := NewRowDecoder(bytes.NewReader(data)).Decode()
+	r.Error(err)
+	r.Nil(row)
+}
+
+func TestRowDecoder_Decode_GivenQuicklist_FlattenList(t *testing.T) {
+	r := require.New(t)
+	first := buildZiplist(2, 0x00, 0x01, 'a', 0x03, 0x01, 'b')
+	second := buildZiplist(1, 0x00, 0xF2)
+	data := []byte{byte(ValueTypeListQuicklist), 0x01, 'l', 0x02}
+	data = append(data, byte(len(first)))
+	data = append(data, first...)
+	data = append(data, byte(len(second)))
+	data = append(data, second...)
+
+	row, err := NewRowDecoder(bytes.NewReader(data)).Decode()
+	r.NoError(err)
+	r.Equal(ValueTypeListQuicklist, row.Type)
+	r.Equal([]string{"a", "b", "1"}, row.Value.(*ListValue).Strings())
+}
+
+func TestRowDecoder_Decode_GivenListpacks_Values(t *testing.T) {
+	r := require.New(t)
+	hash := buildListpack(2, 0x81, 'k', 0x02, 0x81, 'v', 0x02)
+	zset := buildListpack(4, 0x81, 'a', 0x02, 0x83, '1', '.', '5', 0x04, 0x81, 'b', 0x02, 0x02, 0x01)
+	set := buildListpack(2, 0x81, 'a', 0x02, 0x07, 0x01)
+
+	row, err := NewRowDecoder(bytes.NewReader(append([]byte{byte(ValueTypeHashListpack), 0x01, 'h', byte(len(hash))}, hash...))).Decode()
+	r.NoError(err)
+	r.Equal(map[string]string{"k": "v"}, row.Value.(*HashValue).Map())
+
+	row, err = NewRowDecoder(bytes.NewReader(append([]byte{byte(ValueTypeSortedSetListpack), 0x01, 'z', byte(len(zset))}, zset...))).Decode()
+	r.NoError(err)
+	r.Equal(map[string]float64{"a": 1.5, "b": 2}, row.Value.(*SortedSetValue).Scores())
+
+	row, err = NewRowDecoder(bytes.NewReader(append([]byte{byte(ValueTypeSetListpack), 0x01, 's', byte(len(set))}, set...))).Decode()
+	r.NoError(err)
+	r.Equal([]string{"a", "7"}, row.Value.(*SetValue).Strings())
+}
+
+func TestRowDecoder_Decode_GivenQuicklist2_FlattenList(t *testing.T) {
+	r := require.New(t)
+	packed := buildListpack(2, 0x81, 'a', 0x02, 0x03, 0x01)
+	data := []byte{byte(ValueTypeListQuicklist2), 0x01, 'l', 0x02}
+	data = append(data, quicklistNodePacked, byte(len(packed)))
+	data = append(data, packed...)
+	data = append(data, quicklistNodePlain, 0x03, 'b', 'i', 'g')
+
+	row, err := NewRowDecoder(bytes.NewReader(data)).Decode()
+	r.NoError(err)
+	r.Equal(ValueTypeListQuicklist2, row.Type)
+	r.Equal([]string{"a", "3", "big"}, row.Value.(*ListValue).Strings())
+}
+
+func TestRowDecoder_Decode_GivenIntEncodedAndStringValues_ValuesWithIntFlag(t *testing.T) {
+	r := require.New(t)
+	data := []byte{
+		byte(ValueTypeList),
+		0xC0, 0x01, // int encoded key
+		0x02,                // number of elements
+		0x03, '1', '2', '3', // string, which looks like int
+		0xC0, 0x7B, // int encoded 123
+	}
+
+	row, err := NewRowDecoder(bytes.NewReader(data)).Decode()
+	r.NoError(err)
+	r.Equal("1", row.Key)
+	r.True(row.RawKey.IsInt)
+	r.Equal(KindList, row.Kind())
+	r.Equal(&ListValue{Elements: []Bytes{{Val: []byte("123")}, NewIntBytes(123)}}, row.Value)
+}
+
+func TestRowDecoder_Decode_GivenHashZiplist_FieldsInOrder(t *testing.T) {
+	r := require.New(t)
+	zl := buildZiplist(4, 0x00, 0x01, 'b', 0x03, 0xF2, 0x03, 0x01, 'a', 0x03, 0x01, 'v')
+	data := append([]byte{byte(ValueTypeHashmapZiplist), 0x01, 'h', byte(len(zl))}, zl...)
+
+	row, err := NewRowDecoder(bytes.NewReader(data)).Decode()
+	r.NoError(err)
+	r.Equal(map[string]string{"b": "1", "a": "v"}, row.Value.(*HashValue).Map())
+	r.Equal(&HashValue{Fields: []HashField{
+		{Field: Bytes{Val: []byte("b")}, Value: NewIntBytes(1)},
+		{Field: Bytes{Val: []byte("a")}, Value: Bytes{Val: []byte("v")}},
+	}}, row.Value)
+}
+
+func TestRow_Accessors_GivenRowWithValue_OnlyMatchedAccessorOk(t *testing.T) {
+	r := require.New(t)
+	row := &Row{Type: ValueTypeHashListpack, Value: &HashValue{}}
+
+	r.Equal(KindHash, row.Kind())
+	r.Equal(KindHash, row.Type.Kind())
+	_, ok := row.AsHash()
+	r.True(ok)
+	_, ok = row.AsString()
+	r.False(ok)
+	_, ok = row.AsList()
+	r.False(ok)
+	_, ok = row.AsSet()
+	r.False(ok)
+	_, ok = row.AsSortedSet()
+	r.False(ok)
+	_, ok = row.AsStream()
+	r.False(ok)
+	_, ok = row.AsModule()
+	r.False(ok)
+}
+
+func TestRowDecoder_Decode_GivenExpiry_TimeAndUnixMilli(t *testing.T) {
+	r := require.New(t)
+	type testData struct {
+		expiry      []byte
+		expectedRes *time.Time
+		expectedMs  int64
+	}
+	unixTime := func(sec int64, nsec int64) *time.Time {
+		res := time.Unix(sec, nsec)
+		return &res
+	}
+	dp := []testData{
+		// seconds
+		{
+			expiry:      []byte{OpCodeKeyExpirySecond, 0x00, 0xF1, 0x53, 0x65}, // 1700000000 s
+			expectedRes: unixTime(1700000000, 0),
+			expectedMs:  1700000000000,
+		},
+		// milliseconds
+		{
+			expiry:      []byte{OpCodeKeyExpiryMilliseconds, 0x7B, 0x68, 0xE5, 0xCF, 0x8B, 0x01, 0x00, 0x00}, // 1700000000123 ms
+			expectedRes: unixTime(1700000000, 123*int64(time.Millisecond)),
+			expectedMs:  1700000000123,
+		},
+		// milliseconds less than second
+		{
+			expiry:      []byte{OpCodeKeyExpiryMilliseconds, 0xE7, 0x03, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, // 999 ms
+			expectedRes: unixTime(0, 999*int64(time.Millisecond)),
+			expectedMs:  999,
+		},
+		// milliseconds with high bytes
+		{
+			expiry:      []byte{OpCodeKeyExpiryMilliseconds, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00}, // 2^48+1 ms
+			expectedRes: unixTime(281474976710, 657*int64(time.Millisecond)),
+			expectedMs:  281474976710657,
+		},
+		// zero milliseconds
+		{
+			expiry:      []byte{OpCodeKeyExpiryMilliseconds, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+			expectedRes: unixTime(0, 0),
+			expectedMs:  0,
+		},
+		// without expiry
+		{
+			expiry: []byte{},
+		},
+	}
+
+	for k, data := range dp {
+		t.Run(fmt.Sprintf("Data provider #%d", k), func(t *testing.T) {
+			raw := append(append([]byte{}, data.expiry...), byte(ValueTypeString), 0x01, 'k', 0x01, 'v')
+
+			row, err := NewRowDecoder(bytes.NewReader(raw)).Decode()
+			r.NoError(err)
+			r.Equal("k", row.Key)
+			ms, ok := row.ExpiryUnixMilli()
+			if data.expectedRes == nil {
+				r.Nil(row.Expiry)
+				r.False(ok)
+				return
+			}
+			r.NotNil(row.Expiry)
+			r.True(data.expectedRes.Equal(*row.Expiry), "expected %v, actual %v", *data.expectedRes, *row.Expiry)
+			r.True(ok)
+			r.Equal(data.expectedMs, ms)
+		})
+	}
+}
+
+func TestRowDecoder_Decode_GivenUnknownValueType_Err(t *testing.T) {
+	r := require.New(t)
+
+	row, err := NewRowDecoder(bytes.NewReader([]byte{0x06, 0x01, 'k'})).Decode()
+	r.Nil(row)
+	r.EqualError(err, "unexpected value type code 6")
+}
@@ -0,0 +1,140 @@
+package rdb
+
+import (
+	"errors"
+)
+
+// valueSink collect elements of collection and pass them to emit by chunks,
+// when chunk size is 0 all elements are passed by one chunk
+type valueSink struct {
+	kind      Kind
+	chunkSize int
+	emit      func(chunk Value)
+	elements  []Bytes
+	entries   []SortedSetEntry
+	emitted   bool
+}
+
+func newValueSink(kind Kind, chunkSize int, emit func(chunk Value)) *valueSink {
+	return &valueSink{kind: kind, chunkSize: chunkSize, emit: emit}
+}
+
+// grow allocate memory for expected number of elements, but not more than chunk
+func (s *valueSink) grow(n uint64) {
+	if s.chunkSize > 0 && n > uint64(s.chunkSize) {
+		n = uint64(s.chunkSize)
+	}
+	switch s.kind {
+	case KindSortedSet:
+		s.entries = make([]SortedSetEntry, 0, n)
+	case KindHash:
+		s.elements = make([]Bytes, 0, n*2)
+	default:
+		s.elements = make([]Bytes, 0, n)
+	}
+}
+
+// add element of list or set
+func (s *valueSink) add(element Bytes) {
+	s.elements = append(s.elements, element)
+	s.checkChunk()
+}
+
+// addPair add field and value of hash
+func (s *valueSink) addPair(field Bytes, value Bytes) {
+	s.elements = append(s.elements, field, value)
+	s.checkChunk()
+}
+
+// addPairs add fields and values of hash from flat list
+func (s *valueSink) addPairs(pairs []Bytes) error {
+	if len(pairs)%2 != 0 {
+		return errors.New("unexpected number of elements for hash")
+	}
+	for i := 0; i < len(pairs); i += 2 {
+		s.addPair(pairs[i], pairs[i+1])
+	}
+	return nil
+}
+
+func (s *valueSink) addEntry(entry SortedSetEntry) {
+	s.entries = append(s.entries, entry)
+	s.checkChunk()
+}
+
+// addEntries add members and scores of sorted set from ziplist or listpack
+func (s *valueSink) addEntries(l *Entries) error {
+	if len(l.list)%2 != 0 {
+		return errors.New("unexpected number of elements for sorted set")
+	}
+	for i := 0; i < len(l.list); i += 2 {
+		score, err := l.list[i+1].Float()
+		if err != nil {
+			return err
+		}
+		s.addEntry(SortedSetEntry{Member: l.list[i].Bytes(), Score: score})
+	}
+	return nil
+}
+
+func (s *valueSink) len() int {
+	if s.kind == KindHash {
+		return len(s.elements) / 2
+	}
+	return len(s.elements) + len(s.entries)
+}
+
+func (s *valueSink) checkChunk() {
+	if s.chunkSize > 0 && s.len() >= s.chunkSize {
+		s.flush()
+	}
+}
+
+func (s *valueSink) flush() {
+	var chunk Value
+	switch s.kind {
+	case KindList:
+		chunk = &ListValue{Elements: s.elements}
+	case KindSet:
+		chunk = &SetValue{Members: s.elements}
+	case KindSortedSet:
+		chunk = &SortedSetValue{Entries: s.entries}
+	case KindHash:
+		hash := &HashValue{Fields: make([]HashField, 0, len(s.elements)/2)}
+		for i := 0; i+1 < len(s.elements); i += 2 {
+			hash.Fields = append(hash.Fields, HashField{Field: s.elements[i], Value: s.elements[i+1]})
+		}
+		chunk = hash
+	}
+	s.elements, s.entries = nil, nil
+	s.emitted = true
+	s.emit(chunk)
+}
+
+// close pass rest of elements, empty collection is passed as one empty chunk
+func (s *valueSink) close() {
+	if s.len() > 0 || !s.emitted {
+		s.flush()
+	}
+}
+
+// AppendValue append elements of chunk to value with the same kind and return result,
+// it can be used for collecting of chunks passed to ElementConsumer
+func AppendValue(dst Value, chunk Value) Value {
+	switch v := dst.(type) {
+	case nil:
+		return chunk
+	case *ListValue:
+		v.Elements = append(v.Elements, chunk.(*ListValue).Elements...)
+	case *SetValue:
+		v.Members = append(v.Members, chunk.(*SetValue).Members...)
+	case *SortedSetValue:
+		v.Entries = append(v.Entries, chunk.(*SortedSetValue).Entries...)
+	case *HashValue:
+		v.Fields = append(v.Fields, chunk.(*HashValue).Fields...)
+	default:
+		// strings, streams and modules are passed by one chunk
+		return chunk
+	}
+	return dst
+}
@@ -0,0 +1,68 @@
+package rdb
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testElementConsumer struct {
+	testConsumer
+	begin  []string
+	chunks map[string][]Value
+	end    []string
+}
+
+func (c *testElementConsumer) BeginRow(row *Row) {
+	c.begin = append(c.begin, row.Key)
+}
+
+func (c *testElementConsumer) Elements(row *Row, chunk Value) {
+	if c.chunks == nil {
+		c.chunks = make(map[string][]Value)
+	}
+	c.chunks[row.Key] = append(c.chunks[row.Key], chunk)
+}
+
+func (c *testElementConsumer) EndRow(row *Row) {
+	c.end = append(c.end, row.Key)
+}
+
+func TestDecoder_Decode_GivenElementConsumer_ValuesByChunks(t *testing.T) {
+	r := require.New(t)
+	data := []byte("REDIS0009")
+	data = append(data,
+		byte(ValueTypeList), 0x01, 'l', 0x05, 0x01, '1', 0x01, '2', 0x01, '3', 0x01, '4', 0x01, '5',
+		byte(ValueTypeHash), 0x01, 'h', 0x03, 0x01, 'a', 0x01, '1', 0x01, 'b', 0x01, '2', 0x01, 'c', 0x01, '3',
+		byte(ValueTypeSet), 0x01, 's', 0x00,
+		byte(ValueTypeString), 0x01, 'v', 0x01, 'x',
+		OpCodeEOF, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	)
+	c := &testElementConsumer{}
+
+	r.NoError(NewDecoder(bytes.NewBuffer(data), c, WithChunkSize(2)).Decode())
+	r.Empty(c.rows)
+	r.Equal([]string{"l", "h", "s", "v"}, c.begin)
+	r.Equal([]string{"l", "h", "s", "v"}, c.end)
+	r.Equal([]Value{
+		&ListValue{Elements: []Bytes{{Val: []byte("1")}, {Val: []byte("2")}}},
+		&ListValue{Elements: []Bytes{{Val: []byte("3")}, {Val: []byte("4")}}},
+		&ListValue{Elements: []Bytes{{Val: []byte("5")}}},
+	}, c.chunks["l"])
+	r.Len(c.chunks["h"], 2)
+	r.Len(c.chunks["h"][0].(*HashValue).Fields, 2)
+	r.Len(c.chunks["h"][1].(*HashValue).Fields, 1)
+	r.Len(c.chunks["s"], 1)
+	r.Empty(c.chunks["s"][0].(*SetValue).Members)
+	r.Equal([]Value{&StringValue{Val: Bytes{Val: []byte("x")}}}, c.chunks["v"])
+}
+
+func TestAppendValue_GivenChunks_CollectedValue(t *testing.T) {
+	r := require.New(t)
+	var val Value
+
+	val = AppendValue(val, &SortedSetValue{Entries: []SortedSetEntry{{Member: Bytes{Val: []byte("a")}, Score: 1}}})
+	val = AppendValue(val, &SortedSetValue{Entries: []SortedSetEntry{{Member: Bytes{Val: []byte("b")}, Score: 2}}})
+	r.Equal(map[string]float64{"a": 1, "b": 2}, val.(*SortedSetValue).Scores())
+}
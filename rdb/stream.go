@@ -0,0 +1,447 @@
+package rdb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+)
+
+const (
+	streamIDLen = 16
+
+	streamItemFlagDeleted    = 1
+	streamItemFlagSameFields = 2
+)
+
+var ErrStreamBadEntries = errors.New("bad entries of stream listpack")
+
+type StreamID struct {
+	Ms  uint64
+	Seq uint64
+}
+
+func (id StreamID) String() string {
+	return strconv.FormatUint(id.Ms, 10) + "-" + strconv.FormatUint(id.Seq, 10)
+}
+
+// StreamField is binary safe field and value of entry, ints of listpack are marked by IsInt
+type StreamField struct {
+	Field Bytes
+	Value Bytes
+}
+
+type StreamEntry struct {
+	ID     StreamID
+	Fields []StreamField
+}
+
+type StreamPendingEntry struct {
+	ID            StreamID
+	DeliveryTime  time.Time
+	DeliveryCount uint64
+}
+
+type StreamConsumer struct {
+	Name     string
+	SeenTime time.Time
+	// ActiveTime is saved since STREAM_LISTPACKS_3
+	ActiveTime *time.Time
+	Pending    []StreamID
+}
+
+type StreamConsumerGroup struct {
+	Name            string
+	LastDeliveredID StreamID
+	// EntriesRead is saved since STREAM_LISTPACKS_2
+	EntriesRead uint64
+	Pending     []*StreamPendingEntry
+	Consumers   []*StreamConsumer
+}
+
+type Stream struct {
+	Entries []*StreamEntry
+	Length  uint64
+	LastID  StreamID
+	// FirstID, MaxDeletedID and EntriesAdded are saved since STREAM_LISTPACKS_2
+	FirstID      StreamID
+	MaxDeletedID StreamID
+	EntriesAdded uint64
+	Groups       []*StreamConsumerGroup
+}
+
+// StreamDecoder decode stream saved as listpacks with metadata and consumer groups
+// Docs https://github.com/redis/redis/blob/unstable/src/rdb.c, see RDB_TYPE_STREAM_LISTPACKS
+type StreamDecoder struct {
+	r ByteReader
+	t ValueType
+}
+
+func NewStreamDecoder(r ByteReader, t ValueType) *StreamDecoder {
+	return &StreamDecoder{r: r, t: t}
+}
+
+func (d *StreamDecoder) Decode() (*Stream, error) {
+	stream := &Stream{}
+	nodesNum, err := d.decodeLen()
+	if err != nil {
+		return nil, err
+	}
+	for i := uint64(0); i < nodesNum; i++ {
+		nodeKey, err := NewStringDecoder(d.r).DecodeToBytes()
+		if err != nil {
+			return nil, err
+		}
+		if len(nodeKey) != streamIDLen {
+			return nil, errors.New("unexpected length of stream node key")
+		}
+		data, err := NewStringDecoder(d.r).DecodeToBytes()
+		if err != nil {
+			return nil, err
+		}
+		l, err := NewListpack(bufio.NewReader(bytes.NewBuffer(data))).Decode()
+		if err != nil {
+			return nil, err
+		}
+		entries, err := d.decodeEntries(parseStreamID(nodeKey), l.list)
+		if err != nil {
+			return nil, err
+		}
+		stream.Entries = append(stream.Entries, entries...)
+	}
+
+	if stream.Length, err = d.decodeLen(); err != nil {
+		return nil, err
+	}
+	if stream.LastID, err = d.decodeID(); err != nil {
+		return nil, err
+	}
+	if d.t != ValueTypeStreamListpacks {
+		if stream.FirstID, err = d.decodeID(); err != nil {
+			return nil, err
+		}
+		if stream.MaxDeletedID, err = d.decodeID(); err != nil {
+			return nil, err
+		}
+		if stream.EntriesAdded, err = d.decodeLen(); err != nil {
+			return nil, err
+		}
+	}
+
+	groupsNum, err := d.decodeLen()
+	if err != nil {
+		return nil, err
+	}
+	for i := uint64(0); i < groupsNum; i++ {
+		group, err := d.decodeGroup()
+		if err != nil {
+			return nil, err
+		}
+		stream.Groups = append(stream.Groups, group)
+	}
+	return stream, nil
+}
+
+// decodeEntries decode entries of one listpack node,
+// first entries of listpack are master entry: count, deleted, fields number, fields and 0 terminator
+func (d *StreamDecoder) decodeEntries(masterID StreamID, list []*Entry) ([]*StreamEntry, error) {
+	pos := 0
+	next := func() (*Entry, error) {
+		if pos >= len(list) {
+			return nil, ErrStreamBadEntries
+		}
+		pos++
+		return list[pos-1], nil
+	}
+	nextInt := func() (int64, error) {
+		e, err := next()
+		if err != nil {
+			return 0, err
+		}
+		return e.Int()
+	}
+
+	// count and deleted aren't needed, entries are read until the end of listpack
+	if _, err := nextInt(); err != nil {
+		return nil, err
+	}
+	if _, err := nextInt(); err != nil {
+		return nil, err
+	}
+	masterFieldsNum, err := nextInt()
+	if err != nil {
+		return nil, err
+	}
+	masterFields := make([]Bytes, 0, masterFieldsNum)
+	for i := int64(0); i < masterFieldsNum; i++ {
+		e, err := next()
+		if err != nil {
+			return nil, err
+		}
+		masterFields = append(masterFields, e.Bytes())
+	}
+	if _, err := next(); err != nil {
+		return nil, err
+	}
+
+	res := make([]*StreamEntry, 0)
+	for pos < len(list) {
+		flags, err := nextInt()
+		if err != nil {
+			return nil, err
+		}
+		msDiff, err := nextInt()
+		if err != nil {
+			return nil, err
+		}
+		seqDiff, err := nextInt()
+		if err != nil {
+			return nil, err
+		}
+		entry := &StreamEntry{
+			ID: StreamID{Ms: masterID.Ms + uint64(msDiff), Seq: masterID.Seq + uint64(seqDiff)},
+		}
+		if flags&streamItemFlagSameFields != 0 {
+			entry.Fields = make([]StreamField, 0, len(masterFields))
+			for _, field := range masterFields {
+				v, err := next()
+				if err != nil {
+					return nil, err
+				}
+				entry.Fields = append(entry.Fields, StreamField{Field: field, Value: v.Bytes()})
+			}
+		} else {
+			fieldsNum, err := nextInt()
+			if err != nil {
+				return nil, err
+			}
+			entry.Fields = make([]StreamField, 0, fieldsNum)
+			for i := int64(0); i < fieldsNum; i++ {
+				f, err := next()
+				if err != nil {
+					return nil, err
+				}
+				v, err := next()
+				if err != nil {
+					return nil, err
+				}
+				entry.Fields = append(entry.Fields, StreamField{Field: f.Bytes(), Value: v.Bytes()})
+			}
+		}
+		// lp-count of entry is needed only for reverse traversal
+		if _, err := next(); err != nil {
+			return nil, err
+		}
+		if flags&streamItemFlagDeleted != 0 {
+			continue
+		}
+		res = append(res, entry)
+	}
+	return res, nil
+}
+
+func (d *StreamDecoder) decodeGroup() (*StreamConsumerGroup, error) {
+	var err error
+	group := &StreamConsumerGroup{}
+	if group.Name, err = NewStringDecoder(d.r).Decode(); err != nil {
+		return nil, err
+	}
+	if group.LastDeliveredID, err = d.decodeID(); err != nil {
+		return nil, err
+	}
+	if d.t != ValueTypeStreamListpacks {
+		if group.EntriesRead, err = d.decodeLen(); err != nil {
+			return nil, err
+		}
+	}
+
+	pelNum, err := d.decodeLen()
+	if err != nil {
+		return nil, err
+	}
+	for i := uint64(0); i < pelNum; i++ {
+		pe := &StreamPendingEntry{}
+		if pe.ID, err = d.decodeRawID(); err != nil {
+			return nil, err
+		}
+		if pe.DeliveryTime, err = decodeMillisecondTime(d.r); err != nil {
+			return nil, err
+		}
+		if pe.DeliveryCount, err = d.decodeLen(); err != nil {
+			return nil, err
+		}
+		group.Pending = append(group.Pending, pe)
+	}
+
+	consumersNum, err := d.decodeLen()
+	if err != nil {
+		return nil, err
+	}
+	for i := uint64(0); i < consumersNum; i++ {
+		consumer := &StreamConsumer{}
+		if consumer.Name, err = NewStringDecoder(d.r).Decode(); err != nil {
+			return nil, err
+		}
+		if consumer.SeenTime, err = decodeMillisecondTime(d.r); err != nil {
+			return nil, err
+		}
+		if d.t == ValueTypeStreamListpacks3 {
+			activeTime, err := decodeMillisecondTime(d.r)
+			if err != nil {
+				return nil, err
+			}
+			consumer.ActiveTime = &activeTime
+		}
+		consumerPelNum, err := d.decodeLen()
+		if err != nil {
+			return nil, err
+		}
+		for j := uint64(0); j < consumerPelNum; j++ {
+			id, err := d.decodeRawID()
+			if err != nil {
+				return nil, err
+			}
+			consumer.Pending = append(consumer.Pending, id)
+		}
+		group.Consumers = append(group.Consumers, consumer)
+	}
+	return group, nil
+}
+
+func (d *StreamDecoder) decodeLen() (uint64, error) {
+	length, err := DecodeLength(d.r)
+	if err != nil {
+		return 0, err
+	}
+	if length.IsEncodedString() {
+		return 0, errors.New("unexpected type of length for stream")
+	}
+	return length.GetLength64(), nil
+}
+
+// decodeID decode id saved as two lengths
+func (d *StreamDecoder) decodeID() (StreamID, error) {
+	ms, err := d.decodeLen()
+	if err != nil {
+		return StreamID{}, err
+	}
+	seq, err := d.decodeLen()
+	if err != nil {
+		return StreamID{}, err
+	}
+	return StreamID{Ms: ms, Seq: seq}, nil
+}
+
+// decodeRawID decode id saved as 16 raw bytes
+func (d *StreamDecoder) decodeRawID() (StreamID, error) {
+	bytes := make([]byte, streamIDLen)
+	if _, err := io.ReadFull(d.r, bytes); err != nil {
+		return StreamID{}, err
+	}
+	return parseStreamID(bytes), nil
+}
+
+// parseStreamID parse 16 bytes big endian ms and seq
+func parseStreamID(bytes []byte) StreamID {
+	return StreamID{
+		Ms:  binary.BigEndian.Uint64(bytes[0:8]),
+		Seq: binary.BigEndian.Uint64(bytes[8:16]),
+	}
+}
+
+// Skip read stream without decoding of listpacks and consumer groups
+func (d *StreamDecoder) Skip() error {
+	nodesNum, err := d.decodeLen()
+	if err != nil {
+		return err
+	}
+	// every node is node key and listpack
+	if err := d.skipStrings(nodesNum * 2); err != nil {
+		return err
+	}
+	// length and last id, first id, max deleted id and entries added since STREAM_LISTPACKS_2
+	lengths := uint64(3)
+	if d.t != ValueTypeStreamListpacks {
+		lengths += 5
+	}
+	if err := d.skipLens(lengths); err != nil {
+		return err
+	}
+
+	groupsNum, err := d.decodeLen()
+	if err != nil {
+		return err
+	}
+	for i := uint64(0); i < groupsNum; i++ {
+		if err := NewStringDecoder(d.r).Skip(); err != nil {
+			return err
+		}
+		// last delivered id and entries read since STREAM_LISTPACKS_2
+		lengths := uint64(2)
+		if d.t != ValueTypeStreamListpacks {
+			lengths++
+		}
+		if err := d.skipLens(lengths); err != nil {
+			return err
+		}
+		pelNum, err := d.decodeLen()
+		if err != nil {
+			return err
+		}
+		for j := uint64(0); j < pelNum; j++ {
+			// raw id and delivery time
+			if err := skipBytes(d.r, streamIDLen+8); err != nil {
+				return err
+			}
+			if _, err := d.decodeLen(); err != nil {
+				return err
+			}
+		}
+		consumersNum, err := d.decodeLen()
+		if err != nil {
+			return err
+		}
+		for j := uint64(0); j < consumersNum; j++ {
+			if err := NewStringDecoder(d.r).Skip(); err != nil {
+				return err
+			}
+			// seen time and active time since STREAM_LISTPACKS_3
+			times := uint64(8)
+			if d.t == ValueTypeStreamListpacks3 {
+				times += 8
+			}
+			if err := skipBytes(d.r, times); err != nil {
+				return err
+			}
+			consumerPelNum, err := d.decodeLen()
+			if err != nil {
+				return err
+			}
+			if err := skipBytes(d.r, consumerPelNum*streamIDLen); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (d *StreamDecoder) skipStrings(n uint64) error {
+	for i := uint64(0); i < n; i++ {
+		if err := NewStringDecoder(d.r).Skip(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *StreamDecoder) skipLens(n uint64) error {
+	for i := uint64(0); i < n; i++ {
+		if _, err := d.decodeLen(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package rdb
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamDecoder_Decode_GivenStreamListpacks2_Stream(t *testing.T) {
+	r := require.New(t)
+	rawID := []byte{
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x03, 0xE8, // ms 1000
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // seq 0
+	}
+	lp := buildListpack(22,
+		// master entry
+		0x02, 0x01, // count
+		0x01, 0x01, // deleted
+		0x01, 0x01, // number of master fields
+		0x81, 'a', 0x02, // master field
+		0x00, 0x01, // master terminator
+		// entry with master fields
+		0x02, 0x01, // flags
+		0x00, 0x01, // ms diff
+		0x00, 0x01, // seq diff
+		0x01, 0x01, // value
+		0x04, 0x01, // lp-count
+		// entry with own fields
+		0x00, 0x01, // flags
+		0x05, 0x01, // ms diff
+		0x00, 0x01, // seq diff
+		0x01, 0x01, // number of fields
+		0x81, 'b', 0x02, // field
+		0x02, 0x01, // value
+		0x06, 0x01, // lp-count
+		// deleted entry
+		0x03, 0x01, // flags
+		0x06, 0x01, // ms diff
+		0x00, 0x01, // seq diff
+		0x03, 0x01, // value
+		0x04, 0x01, // lp-count
+	)
+	data := []byte{0x01, byte(len(rawID))}
+	data = append(data, rawID...)
+	data = append(data, byte(len(lp)))
+	data = append(data, lp...)
+	data = append(data,
+		0x02,             // length
+		0x43, 0xEE, 0x00, // last id
+		0x43, 0xE8, 0x00, // first id
+		0x43, 0xEE, 0x00, // max deleted id
+		0x03,      // entries added
+		0x01,      // number of groups
+		0x01, 'g', // group name
+		0x43, 0xE8, 0x00, // last delivered id
+		0x01, // entries read
+		0x01, // pel size
+	)
+	data = append(data, rawID...)
+	data = append(data,
+		0xDC, 0x05, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // delivery time 1500 ms
+		0x02,      // delivery count
+		0x01,      // number of consumers
+		0x01, 'c', // consumer name
+		0xD0, 0x07, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // seen time 2000 ms
+		0x01, // consumer pel size
+	)
+	data = append(data, rawID...)
+
+	stream, err := NewStreamDecoder(bytes.NewReader(data), ValueTypeStreamListpacks2).Decode()
+	r.NoError(err)
+	r.Equal([]*StreamEntry{
+		{ID: StreamID{Ms: 1000}, Fields: []StreamField{{Field: Bytes{Val: []byte("a")}, Value: NewIntBytes(1)}}},
+		{ID: StreamID{Ms: 1005}, Fields: []StreamField{{Field: Bytes{Val: []byte("b")}, Value: NewIntBytes(2)}}},
+	}, stream.Entries)
+	r.Equal(uint64(2), stream.Length)
+	r.Equal("1006-0", stream.LastID.String())
+	r.Equal("1000-0", stream.FirstID.String())
+	r.Equal("1006-0", stream.MaxDeletedID.String())
+	r.Equal(uint64(3), stream.EntriesAdded)
+	r.Len(stream.Groups, 1)
+	group := stream.Groups[0]
+	r.Equal("g", group.Name)
+	r.Equal(StreamID{Ms: 1000}, group.LastDeliveredID)
+	r.Equal(uint64(1), group.EntriesRead)
+	r.Len(group.Pending, 1)
+	r.Equal(StreamID{Ms: 1000}, group.Pending[0].ID)
+	r.True(time.Unix(1, 500*int64(time.Millisecond)).Equal(group.Pending[0].DeliveryTime))
+	r.Equal(uint64(2), group.Pending[0].DeliveryCount)
+	r.Len(group.Consumers, 1)
+	r.Equal("c", group.Consumers[0].Name)
+	r.True(time.Unix(2, 0).Equal(group.Consumers[0].SeenTime))
+	r.Nil(group.Consumers[0].ActiveTime)
+	r.Equal([]StreamID{{Ms: 1000}}, group.Consumers[0].Pending)
+}
+
+func TestStreamDecoder_Decode_GivenEmptyStreamListpacks_Stream(t *testing.T) {
+	r := require.New(t)
+	data := []byte{
+		0x00,       // number of listpacks
+		0x00,       // length
+		0x00, 0x00, // last id
+		0x00, // number of groups
+	}
+
+	stream, err := NewStreamDecoder(bytes.NewReader(data), ValueTypeStreamListpacks).Decode()
+	r.NoError(err)
+	r.Empty(stream.Entries)
+	r.Empty(stream.Groups)
+}
+
+func TestStreamDecoder_Decode_GivenBadNodeKey_Err(t *testing.T) {
+	r := require.New(t)
+	data := []byte{0x01, 0x01, 0x00}
+
+	stream, err := NewStreamDecoder(bytes.NewReader(data), ValueTypeStreamListpacks).Decode()
+	r.Error(err)
+	r.Nil(stream)
+}
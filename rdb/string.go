@@ -3,11 +3,33 @@ package rdb
 import (
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"io"
+	"io/ioutil"
+	"math"
 
 	lzf "github.com/zhuyie/golzf"
 )
 
+var ErrEncodedIntBadLength = errors.New("unexpected length of encoded int")
+
+// CompressedStringError is returned when LZF compressed string can't be decompressed
+// or decompressed length isn't equal to length saved in RDB
+type CompressedStringError struct {
+	CompressedLen uint32
+	ExpectedLen   uint32
+	ActualLen     uint32
+	// Err is error of decompression, it's nil when only length is wrong
+	Err error
+}
+
+func (e *CompressedStringError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("can't decompress LZF string with length %d to %d bytes: %v", e.CompressedLen, e.ExpectedLen, e.Err)
+	}
+	return fmt.Sprintf("decompressed LZF string has length %d, expected %d", e.ActualLen, e.ExpectedLen)
+}
+
 // DecodeLengthPrefixedString by length
 // Docs https://rdb.fnordig.de/file_format.html#string-encoding
 func DecodeLengthPrefixedString(r ByteReader, len uint32) (string, error) {
@@ -17,15 +39,19 @@ func DecodeLengthPrefixedString(r ByteReader, len uint32) (string, error) {
 	}
 	return string(res), nil
 }
+
+// DecodeLengthPrefixedBytes read exactly len bytes, io.ErrUnexpectedEOF is returned for truncated string
 func DecodeLengthPrefixedBytes(r ByteReader, len uint32) ([]byte, error) {
 	bytes := make([]byte, len)
-	if _, err := io.ReadFull(r, bytes); err != nil {
+	if err := readFull(r, bytes); err != nil {
 		return nil, err
 	}
 	return bytes, nil
 }
 
 // DecodeEncodedStringInt return encoded int in string
+//
+// Deprecated: it decodes int as big endian unsigned, use DecodeEncodedInt.
 func DecodeEncodedStringInt(r ByteReader, length uint32) (uint32, error) {
 	bytes := make([]byte, length)
 	if _, err := io.ReadFull(r, bytes); err != nil {
@@ -35,6 +61,52 @@ func DecodeEncodedStringInt(r ByteReader, length uint32) (uint32, error) {
 	return binary.BigEndian.Uint32(val), nil
 }
 
+// DecodeEncodedInt return int encoded as string, int is little endian signed with 1, 2 or 4 bytes
+func DecodeEncodedInt(r ByteReader, length uint32) (int64, error) {
+	if length != 1 && length != 2 && length != 4 {
+		return 0, ErrEncodedIntBadLength
+	}
+	bytes := make([]byte, length)
+	if err := readFull(r, bytes); err != nil {
+		return 0, err
+	}
+	switch length {
+	case 1:
+		return int64(int8(bytes[0])), nil
+	case 2:
+		return int64(int16(binary.LittleEndian.Uint16(bytes))), nil
+	}
+	return int64(int32(binary.LittleEndian.Uint32(bytes))), nil
+}
+
+// readFull read exactly len(p) bytes, EOF inside of string means truncated data
+func readFull(r ByteReader, p []byte) error {
+	if _, err := io.ReadFull(r, p); err != nil {
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	return nil
+}
+
+// skipBytes read n bytes to nowhere, bytes are read to update checksum of reader
+func skipBytes(r ByteReader, n uint64) error {
+	if n == 0 {
+		return nil
+	}
+	if _, err := io.CopyN(ioutil.Discard, r, int64(n)); err != nil {
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	return nil
+}
+
+// LengthPrefixedStringDecoder decode only plain strings
+//
+// Deprecated: use StringDecoder, which decodes strings in all encodings.
 type LengthPrefixedStringDecoder struct {
 	r             ByteReader
 	lengthDecoder func(r ByteReader) (*Length, error)
@@ -60,17 +132,24 @@ func (d *LengthPrefixedStringDecoder) Decode() (string, error) {
 	return d.stringDecoder(d.r, length.GetLength())
 }
 
+// DecodeEncodedBytes read LZF compressed string with len bytes and decompress it to decodedLen bytes
 func DecodeEncodedBytes(r ByteReader, len uint32, decodedLen uint32) ([]byte, error) {
 	data := make([]byte, len)
-	if _, err := io.ReadFull(r, data); err != nil {
+	if err := readFull(r, data); err != nil {
 		return nil, err
 	}
 	res := make([]byte, decodedLen)
-	// TODO is not complete
-	lzf.Decompress(data, res)
+	n, err := lzf.Decompress(data, res)
+	if err != nil {
+		return nil, &CompressedStringError{CompressedLen: len, ExpectedLen: decodedLen, Err: err}
+	}
+	if uint32(n) != decodedLen {
+		return nil, &CompressedStringError{CompressedLen: len, ExpectedLen: decodedLen, ActualLen: uint32(n)}
+	}
 	return res, nil
 }
 
+// StringDecoder decode RDB string in any encoding, it's used for keys, values, elements of collections and aux fields
 type StringDecoder struct {
 	r ByteReader
 }
@@ -79,13 +158,75 @@ func NewStringDecoder(r ByteReader) *StringDecoder {
 	return &StringDecoder{r: r}
 }
 
+// DecodeToBytes decode string in any encoding: length prefixed, int or LZF compressed
 func (d *StringDecoder) DecodeToBytes() ([]byte, error) {
-	length, err := DecodeLength(d.r)
+	res, err := d.DecodeBinary()
 	if err != nil {
 		return nil, err
 	}
-	if length.IsEncodedString() {
-		return DecodeEncodedBytes(d.r, length.GetLength(), length.GetVerifyLength())
+	return res.Val, nil
+}
+
+// DecodeBinary decode string in any encoding to binary safe value, which remembers int encoding
+func (d *StringDecoder) DecodeBinary() (Bytes, error) {
+	length, err := DecodeLength(d.r)
+	if err != nil {
+		return Bytes{}, err
+	}
+	var res []byte
+	switch length.GetType() {
+	case LengthTypeEncodedStringInt:
+		val, err := DecodeEncodedInt(d.r, length.GetLength())
+		if err != nil {
+			return Bytes{}, err
+		}
+		return NewIntBytes(val), nil
+	case LengthTypeEncodedStringCompressedStr:
+		res, err = DecodeEncodedBytes(d.r, length.GetLength(), length.GetVerifyLength())
+	default:
+		res, err = DecodeLengthPrefixedBytes(d.r, length.GetLength())
+	}
+	if err != nil {
+		return Bytes{}, err
+	}
+	return Bytes{Val: res}, nil
+}
+
+// Skip read string without decoding, compressed strings aren't decompressed
+func (d *StringDecoder) Skip() error {
+	length, err := DecodeLength(d.r)
+	if err != nil {
+		return err
+	}
+	return skipBytes(d.r, length.GetLength64())
+}
+
+func (d *StringDecoder) Decode() (string, error) {
+	res, err := d.DecodeToBytes()
+	if err != nil {
+		return "", err
+	}
+	return string(res), nil
+}
+
+// EncodeString encode binary safe value to RDB string,
+// int values are encoded as int with minimal size, if it's possible
+func EncodeString(b Bytes) []byte {
+	if b.IsInt {
+		if val, err := b.Int(); err == nil {
+			switch {
+			case val >= math.MinInt8 && val <= math.MaxInt8:
+				return []byte{0xC0, byte(int8(val))}
+			case val >= math.MinInt16 && val <= math.MaxInt16:
+				res := []byte{0xC1, 0, 0}
+				binary.LittleEndian.PutUint16(res[1:], uint16(int16(val)))
+				return res
+			case val >= math.MinInt32 && val <= math.MaxInt32:
+				res := []byte{0xC2, 0, 0, 0, 0}
+				binary.LittleEndian.PutUint32(res[1:], uint32(int32(val)))
+				return res
+			}
+		}
 	}
-	return DecodeLengthPrefixedBytes(d.r, length.GetLength())
+	return append(EncodeLength(uint64(len(b.Val))), b.Val...)
 }
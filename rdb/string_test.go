@@ -2,10 +2,13 @@ package rdb
 
 import (
 	"bytes"
+	"fmt"
 	"io"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	lzf "github.com/zhuyie/golzf"
 )
 
 func TestDecodeLengthPrefixedString_ExpectedNumberOfIncomingBytes_CorrectString(t *testing.T) {
@@ -24,3 +27,130 @@ func TestDecodeLengthPrefixedString_LessThanExpectedNumberOfIncomingBytes_Correc
 	r.Equal("", str)
 	r.Equal(io.ErrUnexpectedEOF, err)
 }
+
+func TestStringDecoder_Decode_GivenEncodedInt_DecimalString(t *testing.T) {
+	r := require.New(t)
+	type testData struct {
+		data        []byte
+		expectedRes string
+	}
+	dp := []testData{
+		{data: []byte{0xC0, 0x7F}, expectedRes: "127"},
+		{data: []byte{0xC0, 0xFF}, expectedRes: "-1"},
+		{data: []byte{0xC1, 0x39, 0x30}, expectedRes: "12345"},
+		{data: []byte{0xC1, 0x00, 0x80}, expectedRes: "-32768"},
+		{data: []byte{0xC2, 0x7A, 0x3C, 0xC3, 0x5C}, expectedRes: "1556298874"},
+	}
+
+	for k, data := range dp {
+		t.Run(fmt.Sprintf("Data provider #%d", k), func(t *testing.T) {
+			str, err := NewStringDecoder(bytes.NewReader(data.data)).Decode()
+			r.NoError(err)
+			r.Equal(data.expectedRes, str)
+		})
+	}
+}
+
+func TestStringDecoder_Decode_GivenCompressedString_DecompressedString(t *testing.T) {
+	r := require.New(t)
+	expected := strings.Repeat("compressed ", 5)
+	compressed := make([]byte, len(expected))
+	n, err := lzf.Compress([]byte(expected), compressed)
+	r.NoError(err)
+
+	data := []byte{0xC3, byte(n), byte(len(expected))}
+	data = append(data, compressed[:n]...)
+
+	str, err := NewStringDecoder(bytes.NewReader(data)).Decode()
+	r.NoError(err)
+	r.Equal(expected, str)
+}
+
+func TestStringDecoder_DecodeBinary_GivenBadCompressedString_CompressedStringError(t *testing.T) {
+	r := require.New(t)
+	expected := strings.Repeat("compressed ", 5)
+	compressed := make([]byte, len(expected))
+	n, err := lzf.Compress([]byte(expected), compressed)
+	r.NoError(err)
+
+	type testData struct {
+		data []byte
+		// expectedDecompressErr is true if decompression fails, false if only length of result is wrong
+		expectedDecompressErr bool
+	}
+	dp := []testData{
+		// decompressed length is less than expected
+		{data: append([]byte{0xC3, byte(n), byte(len(expected) + 1)}, compressed[:n]...)},
+		// decompressed length is greater than expected
+		{data: append([]byte{0xC3, byte(n), byte(len(expected) - 1)}, compressed[:n]...), expectedDecompressErr: true},
+		// corrupted data
+		{data: []byte{0xC3, 0x02, 0x05, 0xFF, 0xFF}, expectedDecompressErr: true},
+	}
+
+	for k, data := range dp {
+		t.Run(fmt.Sprintf("Data provider #%d", k), func(t *testing.T) {
+			_, err := NewStringDecoder(bytes.NewReader(data.data)).DecodeBinary()
+			r.Error(err)
+			csErr, ok := err.(*CompressedStringError)
+			r.True(ok, "unexpected error %v", err)
+			r.Equal(data.expectedDecompressErr, csErr.Err != nil)
+		})
+	}
+}
+
+func TestStringDecoder_DecodeBinary_GivenTruncatedString_UnexpectedEOF(t *testing.T) {
+	r := require.New(t)
+	type testData struct {
+		data []byte
+	}
+	dp := []testData{
+		// plain without data
+		{data: []byte{0x05}},
+		// plain
+		{data: []byte{0x05, 'a', 'b'}},
+		// 14 bits length
+		{data: append([]byte{0x40, 0x64}, make([]byte, 99)...)},
+		// int
+		{data: []byte{0xC2, 0x01}},
+		// compressed
+		{data: []byte{0xC3, 0x05, 0x0A, 0x01}},
+	}
+
+	for k, data := range dp {
+		t.Run(fmt.Sprintf("Data provider #%d", k), func(t *testing.T) {
+			_, err := NewStringDecoder(bytes.NewReader(data.data)).DecodeBinary()
+			r.Equal(io.ErrUnexpectedEOF, err)
+		})
+	}
+}
+
+// oneByteReader return one byte by every Read like slow network connection
+type oneByteReader struct {
+	r *bytes.Reader
+}
+
+func (r *oneByteReader) ReadByte() (byte, error) {
+	return r.r.ReadByte()
+}
+
+func (r *oneByteReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	return r.r.Read(p[:1])
+}
+
+func TestStringDecoder_DecodeBinary_GivenShortReads_WholeString(t *testing.T) {
+	r := require.New(t)
+	expected := strings.Repeat("a", 100)
+	data := append([]byte{0x40, 0x64}, expected...)
+
+	res, err := NewStringDecoder(&oneByteReader{r: bytes.NewReader(data)}).DecodeBinary()
+	r.NoError(err)
+	r.Equal(expected, res.String())
+}
+
+func TestDecodeEncodedInt_GivenBadLength_Err(t *testing.T) {
+	_, err := DecodeEncodedInt(bytes.NewReader([]byte{0x01, 0x02, 0x03}), 3)
+	require.Equal(t, ErrEncodedIntBadLength, err)
+}
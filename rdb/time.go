@@ -0,0 +1,25 @@
+package rdb
+
+import (
+	"encoding/binary"
+	"io"
+	"time"
+)
+
+// decodeMillisecondTime decode 8 bytes little endian unix time in milliseconds
+func decodeMillisecondTime(r ByteReader) (time.Time, error) {
+	bytes := make([]byte, 8)
+	if _, err := io.ReadFull(r, bytes); err != nil {
+		return time.Time{}, err
+	}
+	return fromUnixMilli(int64(binary.LittleEndian.Uint64(bytes))), nil
+}
+
+func fromUnixMilli(ms int64) time.Time {
+	return time.Unix(ms/1000, ms%1000*int64(time.Millisecond))
+}
+
+// unixMilli return unix time in milliseconds, UnixNano isn't used because it overflows after 2262 year
+func unixMilli(t time.Time) int64 {
+	return t.Unix()*1000 + int64(t.Nanosecond())/int64(time.Millisecond)
+}
@@ -0,0 +1,165 @@
+package rdb
+
+// Kind is logical type of value, it doesn't depend on encoding of value in RDB
+type Kind int
+
+const (
+	KindString Kind = iota + 1
+	KindList
+	KindSet
+	KindSortedSet
+	KindHash
+	KindStream
+	KindModule
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindString:
+		return "string"
+	case KindList:
+		return "list"
+	case KindSet:
+		return "set"
+	case KindSortedSet:
+		return "zset"
+	case KindHash:
+		return "hash"
+	case KindStream:
+		return "stream"
+	case KindModule:
+		return "module"
+	}
+	return "unknown"
+}
+
+// Value is decoded value of row, it's one of
+// *StringValue, *ListValue, *SetValue, *SortedSetValue, *HashValue, *Stream or *ModuleValue.
+// Use type switch or accessors of Row to get concrete value.
+type Value interface {
+	Kind() Kind
+}
+
+type StringValue struct {
+	Val Bytes
+}
+
+func (v *StringValue) Kind() Kind {
+	return KindString
+}
+
+func (v *StringValue) String() string {
+	return v.Val.String()
+}
+
+// ListValue contains elements in order of list
+type ListValue struct {
+	Elements []Bytes
+}
+
+func (v *ListValue) Kind() Kind {
+	return KindList
+}
+
+func (v *ListValue) Strings() []string {
+	return bytesToStrings(v.Elements)
+}
+
+// SetValue contains members in order of RDB, members of intset have int flag
+type SetValue struct {
+	Members []Bytes
+}
+
+func (v *SetValue) Kind() Kind {
+	return KindSet
+}
+
+func (v *SetValue) Strings() []string {
+	return bytesToStrings(v.Members)
+}
+
+type SortedSetEntry struct {
+	Member Bytes
+	Score  float64
+}
+
+// SortedSetValue contains entries in order of RDB
+type SortedSetValue struct {
+	Entries []SortedSetEntry
+}
+
+func (v *SortedSetValue) Kind() Kind {
+	return KindSortedSet
+}
+
+func (v *SortedSetValue) Scores() map[string]float64 {
+	res := make(map[string]float64, len(v.Entries))
+	for _, e := range v.Entries {
+		res[e.Member.String()] = e.Score
+	}
+	return res
+}
+
+type HashField struct {
+	Field Bytes
+	Value Bytes
+}
+
+// HashValue contains fields in order of RDB
+type HashValue struct {
+	Fields []HashField
+}
+
+func (v *HashValue) Kind() Kind {
+	return KindHash
+}
+
+func (v *HashValue) Map() map[string]string {
+	res := make(map[string]string, len(v.Fields))
+	for _, f := range v.Fields {
+		res[f.Field.String()] = f.Value.String()
+	}
+	return res
+}
+
+func (s *Stream) Kind() Kind {
+	return KindStream
+}
+
+// ModuleValue is value saved by module with MODULE_2 format
+type ModuleValue struct {
+	ID     uint64
+	Name   string
+	EncVer uint64
+	// Data contains values in order of saving: uint64 for signed and unsigned ints, float32, float64 or Bytes
+	Data []interface{}
+}
+
+func (v *ModuleValue) Kind() Kind {
+	return KindModule
+}
+
+// ModuleAux is auxiliary data of module, which isn't related to keys, e.g. configuration of module
+type ModuleAux struct {
+	ID     uint64
+	Name   string
+	EncVer uint64
+	// When is flag of module, data is saved before or after keys
+	When uint64
+	// Data contains values like ModuleValue.Data
+	Data []interface{}
+}
+
+const moduleTypeNameCharSet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"
+
+// moduleTypeName return 9 chars name and encoding version from module type id
+func moduleTypeName(id uint64) (string, uint64) {
+	encVer := id & 1023
+	id >>= 10
+	name := make([]byte, 9)
+	for j := 0; j < 9; j++ {
+		name[8-j] = moduleTypeNameCharSet[id&63]
+		id >>= 6
+	}
+	return string(name), encVer
+}
@@ -19,19 +19,57 @@ type Entry struct {
 	lengthPrevEntry uint32
 	t               EntryType
 	strVal          string
-	intVal          uint64
+	intVal          int64
 }
 
 func (e *Entry) String() string {
 	switch e.t {
 	case EntryTypeInt:
-		return strconv.FormatUint(e.intVal, 10)
+		return strconv.FormatInt(e.intVal, 10)
 	case EntryTypeString:
 		return e.strVal
 	}
 	return ""
 }
 
+// Bytes return entry as binary safe value
+func (e *Entry) Bytes() Bytes {
+	if e.t == EntryTypeInt {
+		return NewIntBytes(e.intVal)
+	}
+	return Bytes{Val: []byte(e.strVal)}
+}
+
+// Float return entry as float, it's used for scores of sorted set
+func (e *Entry) Float() (float64, error) {
+	switch e.t {
+	case EntryTypeInt:
+		return float64(e.intVal), nil
+	case EntryTypeString:
+		val, err := strconv.ParseFloat(e.strVal, 64)
+		if err != nil {
+			return 0, errors.New("unexpected format of float in entry")
+		}
+		return val, nil
+	}
+	return 0, errors.New("unexpected type of entry")
+}
+
+// Int return entry as int, it's used for counters and flags of stream
+func (e *Entry) Int() (int64, error) {
+	switch e.t {
+	case EntryTypeInt:
+		return e.intVal, nil
+	case EntryTypeString:
+		val, err := strconv.ParseInt(e.strVal, 10, 64)
+		if err != nil {
+			return 0, errors.New("unexpected format of int in entry")
+		}
+		return val, nil
+	}
+	return 0, errors.New("unexpected type of entry")
+}
+
 type ZipEntry struct {
 	r ByteReader
 }
@@ -112,35 +150,49 @@ func (z *ZipEntry) decodeSpecialFlagAndBin() (*Entry, error) {
 		return &Entry{t: EntryTypeString, strVal: string(val)}, nil
 	}
 
-	switch b >> 4 {
+	switch b {
 	// Integer encoded as 16 bit signed (2 bytes)
-	case 0xC:
+	case 0xC0:
 		bytes := make([]byte, 2)
 		if _, err := io.ReadFull(z.r, bytes); err != nil {
 			return nil, err
 		}
-		return &Entry{t: EntryTypeInt, intVal: uint64(binary.LittleEndian.Uint16(bytes))}, nil
+		return &Entry{t: EntryTypeInt, intVal: int64(int16(binary.LittleEndian.Uint16(bytes)))}, nil
 	// Integer encoded as 32 bit signed (4 bytes)
-	case 0xD:
+	case 0xD0:
 		bytes := make([]byte, 4)
 		if _, err := io.ReadFull(z.r, bytes); err != nil {
 			return nil, err
 		}
-		return &Entry{t: EntryTypeInt, intVal: uint64(binary.LittleEndian.Uint32(bytes))}, nil
+		return &Entry{t: EntryTypeInt, intVal: int64(int32(binary.LittleEndian.Uint32(bytes)))}, nil
 	// Integer encoded as 64 bit signed (8 bytes)
-	case 0xE:
+	case 0xE0:
 		bytes := make([]byte, 8)
 		if _, err := io.ReadFull(z.r, bytes); err != nil {
 			return nil, err
 		}
-		return &Entry{t: EntryTypeInt, intVal: binary.LittleEndian.Uint64(bytes)}, nil
-	// 	Integer encoded as 24 bit signed (3 bytes)
-	case 0xF:
-		bytes := make([]byte, 8)
+		return &Entry{t: EntryTypeInt, intVal: int64(binary.LittleEndian.Uint64(bytes))}, nil
+	// Integer encoded as 24 bit signed (3 bytes)
+	case 0xF0:
+		bytes := make([]byte, 3)
 		if _, err := io.ReadFull(z.r, bytes); err != nil {
 			return nil, err
 		}
-		return &Entry{t: EntryTypeInt, intVal: binary.LittleEndian.Uint64(bytes)}, nil
+		// shift to top of int32 and back for sign extension
+		val := int32(uint32(bytes[0])<<8|uint32(bytes[1])<<16|uint32(bytes[2])<<24) >> 8
+		return &Entry{t: EntryTypeInt, intVal: int64(val)}, nil
+	// Integer encoded as 8 bit signed (1 byte)
+	case 0xFE:
+		sb, err := z.r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		return &Entry{t: EntryTypeInt, intVal: int64(int8(sb))}, nil
+	}
+
+	// 4 bit immediate integer from 0 to 12, encoded as 1 to 13
+	if b >= 0xF1 && b <= 0xFD {
+		return &Entry{t: EntryTypeInt, intVal: int64(b&0x0F) - 1}, nil
 	}
 
 	return nil, errors.New("unexpected special flag")
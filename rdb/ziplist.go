@@ -26,6 +26,24 @@ func NewEntries(size uint32, tail uint32, len uint16) *Entries {
 	}
 }
 
+// Strings return all entries as strings
+func (e *Entries) Strings() []string {
+	res := make([]string, 0, len(e.list))
+	for _, entry := range e.list {
+		res = append(res, entry.String())
+	}
+	return res
+}
+
+// Bytes return all entries as binary safe values
+func (e *Entries) Bytes() []Bytes {
+	res := make([]Bytes, 0, len(e.list))
+	for _, entry := range e.list {
+		res = append(res, entry.Bytes())
+	}
+	return res
+}
+
 type Ziplist struct {
 	r ByteReader
 }
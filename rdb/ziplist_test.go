@@ -0,0 +1,63 @@
+package rdb
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// buildZiplist make ziplist blob, zlbytes and zltail aren't used by decoder
+func buildZiplist(num uint16, entries ...byte) []byte {
+	data := []byte{
+		0x00, 0x00, 0x00, 0x00, // zlbytes
+		0x00, 0x00, 0x00, 0x00, // zltail
+		byte(num), byte(num >> 8), // zllen
+	}
+	data = append(data, entries...)
+	return append(data, 0xFF)
+}
+
+func TestZiplist_Decode_GivenAllEntryEncodings_Entries(t *testing.T) {
+	r := require.New(t)
+	data := buildZiplist(8,
+		0x00, 0x01, 'a', // 6 bits string
+		0x03, 0x40, 0x02, 'b', 'c', // 14 bits string
+		0x04, 0xC0, 0x39, 0x30, // int16
+		0x04, 0xD0, 0xFF, 0xFF, 0xFF, 0xFF, // int32
+		0x06, 0xE0, 0x01, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, // int64
+		0x0A, 0xF0, 0xFE, 0xFF, 0xFF, // int24
+		0x04, 0xFE, 0x80, // int8
+		0x03, 0xF6, // 4 bits immediate
+	)
+
+	l, err := NewZiplist(bytes.NewReader(data)).Decode()
+	r.NoError(err)
+	r.Equal([]string{"a", "bc", "12345", "-1", "4294967297", "-2", "-128", "5"}, l.Strings())
+}
+
+func TestZiplist_Decode_GivenBadEndByte_Err(t *testing.T) {
+	r := require.New(t)
+	data := buildZiplist(1, 0x00, 0x01, 'a')
+	data[len(data)-1] = 0x00
+
+	l, err := NewZiplist(bytes.NewReader(data)).Decode()
+	r.Error(err)
+	r.Nil(l)
+	r.Equal(ErrUnexpectedEndByte, err)
+}
+
+func TestEntry_Float_GivenEntries_Floats(t *testing.T) {
+	r := require.New(t)
+
+	val, err := (&Entry{t: EntryTypeInt, intVal: -3}).Float()
+	r.NoError(err)
+	r.Equal(float64(-3), val)
+
+	val, err = (&Entry{t: EntryTypeString, strVal: "1.25"}).Float()
+	r.NoError(err)
+	r.Equal(1.25, val)
+
+	_, err = (&Entry{t: EntryTypeString, strVal: "bad"}).Float()
+	r.Error(err)
+}
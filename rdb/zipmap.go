@@ -1,16 +1,23 @@
 package rdb
 
 import (
-	"bufio"
 	"bytes"
 	"encoding/binary"
 	"errors"
 	"io"
-	"log"
 )
 
+const (
+	zipMapBigLen = 254
+	zipMapEnd    = 0xFF
+)
+
+var ErrZipMapUnexpectedLen = errors.New("unexpected len of entry in zip map")
+
+// ZipMap decode zipmap encoded hash, it's used by old RDB versions
+// Docs https://rdb.fnordig.de/file_format.html#zipmap-encoding
 type ZipMap struct {
-	r ByteReader
+	r    ByteReader
 	rowr ByteReader
 }
 
@@ -19,23 +26,37 @@ func NewZipMap(r ByteReader) *ZipMap {
 }
 
 func (z *ZipMap) Decode() (map[string]string, error) {
+	pairs, err := z.DecodePairs()
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]string, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		res[pairs[i].String()] = pairs[i+1].String()
+	}
+	return res, nil
+}
+
+// DecodePairs decode zipmap to binary safe key and value pairs in order of zipmap
+func (z *ZipMap) DecodePairs() ([]Bytes, error) {
 	data, err := NewStringDecoder(z.rowr).DecodeToBytes()
 	if err != nil {
 		return nil, err
 	}
-	log.Println(data)
-	log.Println(string(data))
 
-	z.r = bufio.NewReader(bytes.NewBuffer(data))
+	z.r = bytes.NewReader(data)
 
-	test := make([]byte, 8)
-	io.ReadFull(z.r, test)
-	if _, err := z.decodeLength(); err != nil {
+	// zmlen is only a hint, it's not usable when >= 254 and entries must be read until end byte
+	zmLen, err := z.decodeLength()
+	if err != nil {
 		return nil, err
 	}
+	size := 0
+	if zmLen.isUsable() {
+		size = int(zmLen)
+	}
 
-
-	res := make(map[string]string)
+	res := make([]Bytes, 0, size*2)
 	for {
 		b, isEnd, err := z.decodeHeader()
 		if err != nil {
@@ -54,14 +75,14 @@ func (z *ZipMap) Decode() (map[string]string, error) {
 			return nil, err
 		}
 
-		res[k] = v
+		res = append(res, Bytes{Val: k}, Bytes{Val: v})
 	}
 }
 
 type length uint8
 
 func (l length) isUsable() bool {
-	return l < 254
+	return l < zipMapBigLen
 }
 
 func (z *ZipMap) decodeLength() (length, error) {
@@ -75,9 +96,9 @@ func (z *ZipMap) decodeLength() (length, error) {
 func (z *ZipMap) decodeHeader() (byte, bool, error) {
 	b, err := z.r.ReadByte()
 	if err != nil {
-		return 0, false, nil
+		return 0, false, err
 	}
-	if b == 0xFF {
+	if b == zipMapEnd {
 		return b, true, nil
 	}
 	return b, false, nil
@@ -86,73 +107,61 @@ func (z *ZipMap) decodeHeader() (byte, bool, error) {
 func (z *ZipMap) decodeLen() (uint32, error) {
 	b, err := z.r.ReadByte()
 	if err != nil {
-		return 0, nil
+		return 0, err
 	}
 	return z.parseLen(b)
 }
 
 func (z *ZipMap) parseLen(b byte) (uint32, error) {
 	switch b {
-	case 253:
+	case zipMapBigLen:
 		bytes := make([]byte, 4)
 		if _, err := io.ReadFull(z.r, bytes); err != nil {
 			return 0, err
 		}
 
-		return binary.BigEndian.Uint32(bytes), nil
-	case 254, 255:
-		return 0, errors.New("unexpected len of entry in zip map")
+		return binary.LittleEndian.Uint32(bytes), nil
+	case zipMapEnd:
+		return 0, ErrZipMapUnexpectedLen
 	default:
 		return uint32(b), nil
 	}
 }
 
-func (z *ZipMap) decodeEnd() error {
-	b, err := z.r.ReadByte()
-	if err != nil {
-		return err
-	}
-	if b != 0xFF {
-		return errors.New("unexpected end byte")
-	}
-	return nil
-}
-
-func (z *ZipMap) decodeKey(b byte) (string, error) {
+func (z *ZipMap) decodeKey(b byte) ([]byte, error) {
 	l, err := z.parseLen(b)
 	if err != nil {
-		return "", nil
+		return nil, err
 	}
 
 	res := make([]byte, l)
 	if _, err := io.ReadFull(z.r, res); err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return string(res), nil
+	return res, nil
 }
 
-func (z *ZipMap) decodeVal() (string, error) {
+func (z *ZipMap) decodeVal() ([]byte, error) {
 	l, err := z.decodeLen()
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	// len of free bytes after value
 	freeLen, err := z.r.ReadByte()
 	if err != nil {
-		return "", err
-
+		return nil, err
 	}
 	// read val
 	res := make([]byte, l)
 	if _, err := io.ReadFull(z.r, res); err != nil {
-		return "", err
+		return nil, err
 	}
 	// read free bytes
 	trash := make([]byte, freeLen)
 	if _, err := io.ReadFull(z.r, trash); err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return string(res), nil
+	return res, nil
 }
@@ -0,0 +1,68 @@
+package rdb
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestZipMap_Decode_GivenZipMap_Map(t *testing.T) {
+	r := require.New(t)
+	zm := []byte{
+		0x02,                // zmlen
+		0x03, 'f', 'o', 'o', // key
+		0x03, 0x00, 'b', 'a', 'r', // val without free bytes
+		0x05, 'h', 'e', 'l', 'l', 'o', // key
+		0x05, 0x02, 'w', 'o', 'r', 'l', 'd', 0x00, 0x00, // val with free bytes
+		0xFF, // end
+	}
+	data := append([]byte{byte(len(zm))}, zm...)
+
+	res, err := NewZipMap(bytes.NewReader(data)).Decode()
+	r.NoError(err)
+	r.Equal(map[string]string{"foo": "bar", "hello": "world"}, res)
+}
+
+func TestZipMap_Decode_GivenBigLen_Map(t *testing.T) {
+	r := require.New(t)
+	bigVal := bytes.Repeat([]byte{'v'}, 300)
+	val253 := bytes.Repeat([]byte{'w'}, 253)
+	zm := []byte{
+		0xFE,                              // zmlen isn't usable
+		0xFE, 0x01, 0x00, 0x00, 0x00, 'k', // key with 4 bytes len
+		0xFE, 0x2C, 0x01, 0x00, 0x00, 0x00, // val with 4 bytes len 300 and without free bytes
+	}
+	zm = append(zm, bigVal...)
+	zm = append(zm, 0x01, 'l', 0xFD, 0x00) // key, val with single byte len 253
+	zm = append(zm, val253...)
+	zm = append(zm, 0xFF) // end
+	data := append([]byte{0x40 | byte(len(zm)>>8), byte(len(zm))}, zm...)
+
+	res, err := NewZipMap(bytes.NewReader(data)).Decode()
+	r.NoError(err)
+	r.Equal(map[string]string{"k": string(bigVal), "l": string(val253)}, res)
+}
+
+func TestZipMap_Decode_GivenUnexpectedLen_Err(t *testing.T) {
+	r := require.New(t)
+	zm := []byte{0x01, 0x01, 'k', 0xFF}
+	data := append([]byte{byte(len(zm))}, zm...)
+
+	res, err := NewZipMap(bytes.NewReader(data)).Decode()
+	r.Error(err)
+	r.Nil(res)
+	r.Equal(ErrZipMapUnexpectedLen, err)
+}
+
+func TestZipMap_Decode_GivenWithoutEnd_Err(t *testing.T) {
+	r := require.New(t)
+	zm := []byte{0x01, 0x01, 'k', 0x01, 0x00, 'v'}
+	data := append([]byte{byte(len(zm))}, zm...)
+
+	res, err := NewZipMap(bytes.NewReader(data)).Decode()
+	r.Error(err)
+	r.Nil(res)
+	r.Equal(io.EOF, err)
+}
@@ -4,21 +4,29 @@ type CmdName string
 type ConfigKey string
 
 const (
-	CmdDel     CmdName = "del"
-	CmdLPush   CmdName = "lpush"
-	CmdSelect  CmdName = "select"
-	CmdSet     CmdName = "set"
-	CmdSetex   CmdName = "setex"
-	CmdSync    CmdName = "sync"
-	CmdFlushDB CmdName = "flushdb"
-	CmdHset    CmdName = "hset"
-	CmdConfig  CmdName = "config"
+	CmdDel      CmdName = "del"
+	CmdLPush    CmdName = "lpush"
+	CmdSelect   CmdName = "select"
+	CmdSet      CmdName = "set"
+	CmdSetex    CmdName = "setex"
+	CmdSync     CmdName = "sync"
+	CmdPSync    CmdName = "psync"
+	CmdFlushDB  CmdName = "flushdb"
+	CmdHset     CmdName = "hset"
+	CmdConfig   CmdName = "config"
+	CmdReplConf CmdName = "replconf"
 
 	ConfigSubCmdSet = "set"
 	ConfigSubCmdGet = "get"
 
-	ConfigKeyHashMaxZiplistValue ConfigKey = "hash-max-ziplist-value"
+	ReplConfSubCmdAck           = "ack"
+	ReplConfSubCmdListeningPort = "listening-port"
+	ReplConfSubCmdIPAddress     = "ip-address"
+	ReplConfSubCmdCapa          = "capa"
+
+	ConfigKeyHashMaxZiplistValue   ConfigKey = "hash-max-ziplist-value"
 	ConfigKeyHashMaxZiplistEntries ConfigKey = "hash-max-ziplist-entries"
+	ConfigKeyListMaxZiplistSize    ConfigKey = "list-max-ziplist-size"
 )
 
 type Cmd []string
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"io"
 	"log"
+	"net"
 	"strconv"
 	"strings"
 )
@@ -30,8 +31,16 @@ const (
 
 	// LF это символ \n
 	LF = 0xa
+
+	// EOFMarkPrefix is prefix of bulk string length in diskless replication, e.g. `$EOF:<40 bytes mark>`
+	EOFMarkPrefix = "EOF:"
+
+	// EOFMarkLen is length of mark, which is sent before and after RDB payload in diskless replication
+	EOFMarkLen = 40
 )
 
+var ErrBadEOFMark = errors.New("unexpected length of eof mark")
+
 type Conn struct {
 	r *bufio.Reader
 	w io.Writer
@@ -48,6 +57,19 @@ func (c *Conn) GetReader() *bufio.Reader {
 	return c.r
 }
 
+// GetLocalPort return local port of tcp connection, 0 is returned for other connections
+func (c *Conn) GetLocalPort() int {
+	nc, ok := c.w.(net.Conn)
+	if !ok {
+		return 0
+	}
+	addr, ok := nc.LocalAddr().(*net.TCPAddr)
+	if !ok {
+		return 0
+	}
+	return addr.Port
+}
+
 func (c *Conn) ExecCmd(cmd Cmd) (*Result, error) {
 	if err := c.WriteCmd(cmd); err != nil {
 		return nil, err
@@ -71,7 +93,7 @@ func (c *Conn) WriteCmd(cmd Cmd) error {
 
 	n, err := c.w.Write(cmdBytes)
 	if err != nil {
-		return nil
+		return err
 	}
 	if n != len(cmdBytes) {
 		return errors.New("unexpected num of written bytes")
@@ -124,7 +146,17 @@ func (c *Conn) DecodeCmdResult(code byte, data []byte) (*Result, error) {
 			return nil, err
 		}
 	case BulkStringOpcode:
-		res.intVal, err = strconv.ParseInt(strings.TrimRight(string(data), "\r\n"), 10, 0)
+		str := strings.TrimRight(string(data), "\r\n")
+		if strings.HasPrefix(str, EOFMarkPrefix) {
+			mark := str[len(EOFMarkPrefix):]
+			if len(mark) != EOFMarkLen {
+				return nil, ErrBadEOFMark
+			}
+			res.eofMark = []byte(mark)
+			res.intVal = -1
+			return res, nil
+		}
+		res.intVal, err = strconv.ParseInt(str, 10, 0)
 		if err != nil {
 			return nil, err
 		}
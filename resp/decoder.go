@@ -4,8 +4,12 @@ import (
 	"bufio"
 	"context"
 	"errors"
+	"io"
 	"log"
 	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type Consumer interface {
@@ -19,25 +23,109 @@ func (c *LogConsumer) Cmd(cmd Cmd) {
 	log.Printf("Cmd: %#v", cmd)
 }
 
+// DefaultAckInterval is interval of REPLCONF ACK, which is used by redis replicas
+const DefaultAckInterval = time.Second
+
 type Decoder struct {
-	r      *bufio.Reader
-	c      Consumer
-	stopCh chan bool
+	// offset is first for atomic access on 32-bit platforms
+	offset      int64
+	r           *bufio.Reader
+	c           Consumer
+	stopCh      chan bool
+	ackConn     *Conn
+	ackInterval time.Duration
+	ackErrCh    chan error
+	ackDoneCh   chan struct{}
+	// ackFailedCh is closed on write error of ACK, decoding is stopped before the next cmd then
+	ackFailedCh chan struct{}
+	// ackOnce start sending of ACK by Decode or mark it as never started by Shutdown
+	ackOnce sync.Once
+}
+
+type DecoderOption func(d *Decoder)
+
+// WithOffset set replication offset of master before the first cmd, e.g. offset from FULLRESYNC reply
+func WithOffset(offset int64) DecoderOption {
+	return func(d *Decoder) {
+		d.offset = offset
+	}
+}
+
+// WithAck send `REPLCONF ACK <offset>` by conn every interval while cmds are decoded,
+// master use it to measure lag of replica, for WAIT and min-replicas-to-write.
+// DefaultAckInterval is used if interval isn't positive.
+// Conn must be connection of reader, which isn't used for other cmds.
+func WithAck(conn *Conn, interval time.Duration) DecoderOption {
+	return func(d *Decoder) {
+		if interval <= 0 {
+			interval = DefaultAckInterval
+		}
+		d.ackConn = conn
+		d.ackInterval = interval
+	}
 }
 
-func NewDecoder(r *bufio.Reader, consumer Consumer) *Decoder {
-	return &Decoder{
+func NewDecoder(r *bufio.Reader, consumer Consumer, opts ...DecoderOption) *Decoder {
+	d := &Decoder{
 		r:      r,
 		c:      consumer,
 		stopCh: make(chan bool),
 	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	if d.ackConn != nil {
+		d.ackErrCh = make(chan error, 1)
+		d.ackDoneCh = make(chan struct{})
+		d.ackFailedCh = make(chan struct{})
+	}
+	return d
+}
+
+// Offset return replication offset, it's increased by size of every cmd after it's applied by consumer.
+// Offset is safe for concurrent use, replication can be continued by PSYNC from Offset()+1.
+func (d *Decoder) Offset() int64 {
+	return atomic.LoadInt64(&d.offset)
 }
 
+// Decode read cmds till error or Shutdown. With WithAck write error of ACK is returned immediately,
+// even if reading of cmds is blocked, connection must be closed by caller to stop reading then.
+// Consumer doesn't get cmds after write error of ACK.
 func (d *Decoder) Decode() error {
+	if d.ackConn == nil {
+		return d.decode()
+	}
+	started := false
+	d.ackOnce.Do(func() {
+		started = true
+	})
+	if !started {
+		// Shutdown is called before Decode
+		return nil
+	}
+	decodeDoneCh := make(chan struct{})
+	defer close(decodeDoneCh)
+	go d.ack(decodeDoneCh)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- d.decode()
+	}()
+	select {
+	case err := <-errCh:
+		return err
+	case err := <-d.ackErrCh:
+		return err
+	}
+}
+
+func (d *Decoder) decode() error {
 	for {
 		select {
 		case <-d.stopCh:
 			return nil
+		case <-d.ackFailedCh:
+			return nil
 		default:
 			arrNumBytes, err := d.r.ReadSlice('\n')
 			if err != nil {
@@ -46,6 +134,7 @@ func (d *Decoder) Decode() error {
 			if arrNumBytes[0] != ArrayOpcode {
 				return errors.New("unexpected symbol")
 			}
+			n := int64(len(arrNumBytes))
 			arrNum, err := strconv.Atoi(string(arrNumBytes[1 : len(arrNumBytes)-2]))
 			if err != nil {
 				return errors.New("can't convert arrnum string to int")
@@ -53,22 +142,74 @@ func (d *Decoder) Decode() error {
 			cmd := make(Cmd, 0, arrNum)
 			for arrNum > 0 {
 				arrNum--
-				_, err := d.r.ReadSlice('\n')
+				argLenBytes, err := d.r.ReadSlice('\n')
 				if err != nil {
 					return errors.New("can't read arg len")
 				}
-				val, err := d.r.ReadSlice('\n')
-				if err != nil {
+				n += int64(len(argLenBytes))
+				if argLenBytes[0] != BulkStringOpcode {
+					return errors.New("unexpected symbol")
+				}
+				argLen, err := strconv.Atoi(string(argLenBytes[1 : len(argLenBytes)-2]))
+				if err != nil || argLen < 0 {
+					return errors.New("can't convert arg len string to int")
+				}
+				// value is read by length, it can contain \n
+				val := make([]byte, argLen+2)
+				if _, err := io.ReadFull(d.r, val); err != nil {
 					return errors.New("can't read arg val")
 				}
-				cmd = append(cmd, string(val[:len(val)-2]))
+				n += int64(len(val))
+				cmd = append(cmd, string(val[:argLen]))
+			}
+			select {
+			case <-d.ackFailedCh:
+				// error of ACK is already returned by Decode
+				return nil
+			default:
 			}
 			d.c.Cmd(cmd)
+			atomic.AddInt64(&d.offset, n)
 		}
 	}
 }
 
+// Shutdown stop decoding after current cmd and wait for stop of ACK sending
 func (d *Decoder) Shutdown(ctx context.Context) error {
 	close(d.stopCh)
-	return nil
+	if d.ackDoneCh == nil {
+		return nil
+	}
+	d.ackOnce.Do(func() {
+		// Decode isn't called, so there isn't goroutine of ACK
+		close(d.ackDoneCh)
+	})
+	select {
+	case <-d.ackDoneCh:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ack send offset to master till decoding is stopped, write error is returned by Decode
+func (d *Decoder) ack(decodeDoneCh chan struct{}) {
+	defer close(d.ackDoneCh)
+	ticker := time.NewTicker(d.ackInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.stopCh:
+			return
+		case <-decodeDoneCh:
+			return
+		case <-ticker.C:
+			offset := strconv.FormatInt(d.Offset(), 10)
+			if err := d.ackConn.WriteCmd(NewCmd(CmdReplConf, ReplConfSubCmdAck, offset)); err != nil {
+				d.ackErrCh <- err
+				close(d.ackFailedCh)
+				return
+			}
+		}
+	}
 }
@@ -0,0 +1,186 @@
+package resp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testConsumer struct {
+	cmds chan Cmd
+}
+
+func newTestConsumer() *testConsumer {
+	return &testConsumer{cmds: make(chan Cmd, 10)}
+}
+
+func (c *testConsumer) Cmd(cmd Cmd) {
+	c.cmds <- cmd
+}
+
+// testWriter send every write to channel or return err
+type testWriter struct {
+	writes chan string
+	err    error
+}
+
+func (w *testWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	w.writes <- string(p)
+	return len(p), nil
+}
+
+type testConn struct {
+	io.Reader
+	io.Writer
+}
+
+func ackCmd(offset string) string {
+	return "*3\r\n$8\r\nreplconf\r\n$3\r\nack\r\n$" + strconv.Itoa(len(offset)) + "\r\n" + offset + "\r\n"
+}
+
+func TestDecoder_WithAck_GivenCmd_AckWithCurrentOffset(t *testing.T) {
+	r := require.New(t)
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	w := &testWriter{writes: make(chan string, 100)}
+	conn := NewConn(testConn{Reader: pr, Writer: w})
+	c := newTestConsumer()
+	d := NewDecoder(conn.GetReader(), c, WithOffset(10), WithAck(conn, 10*time.Millisecond))
+	go d.Decode()
+
+	r.Equal(ackCmd("10"), <-w.writes)
+
+	_, err := pw.Write([]byte("*1\r\n$4\r\nPING\r\n"))
+	r.NoError(err)
+	r.Equal(Cmd{"PING"}, <-c.cmds)
+	for ack := range w.writes {
+		if ack == ackCmd("24") {
+			break
+		}
+		r.Equal(ackCmd("10"), ack)
+	}
+
+	r.NoError(d.Shutdown(context.Background()))
+	// ACK isn't sent after shutdown
+	for len(w.writes) > 0 {
+		<-w.writes
+	}
+	time.Sleep(30 * time.Millisecond)
+	r.Len(w.writes, 0)
+}
+
+func TestDecoder_WithAck_GivenWriteErrorAndBlockedRead_Err(t *testing.T) {
+	r := require.New(t)
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	writeErr := errors.New("write error")
+	conn := NewConn(testConn{Reader: pr, Writer: &testWriter{err: writeErr}})
+	c := newTestConsumer()
+	d := NewDecoder(conn.GetReader(), c, WithAck(conn, 10*time.Millisecond))
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- d.Decode()
+	}()
+	select {
+	case err := <-errCh:
+		r.Equal(writeErr, err)
+	case <-time.After(time.Second):
+		r.Fail("write error isn't returned by Decode")
+	}
+
+	// cmd, which is read after error, isn't passed to consumer
+	_, err := pw.Write([]byte("*1\r\n$4\r\nPING\r\n"))
+	r.NoError(err)
+	time.Sleep(30 * time.Millisecond)
+	r.Len(c.cmds, 0)
+}
+
+func TestDecoder_Shutdown_GivenNotStartedDecode_Nil(t *testing.T) {
+	r := require.New(t)
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	w := &testWriter{writes: make(chan string, 100)}
+	conn := NewConn(testConn{Reader: pr, Writer: w})
+	d := NewDecoder(conn.GetReader(), newTestConsumer(), WithAck(conn, 10*time.Millisecond))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	r.NoError(d.Shutdown(ctx))
+
+	// Decode after Shutdown doesn't start ACK
+	r.NoError(d.Decode())
+	time.Sleep(30 * time.Millisecond)
+	r.Len(w.writes, 0)
+}
+
+func TestDecoder_Shutdown_GivenStartedDecode_Nil(t *testing.T) {
+	r := require.New(t)
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	w := &testWriter{writes: make(chan string, 100)}
+	conn := NewConn(testConn{Reader: pr, Writer: w})
+	c := newTestConsumer()
+	d := NewDecoder(conn.GetReader(), c, WithAck(conn, 10*time.Millisecond))
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- d.Decode()
+	}()
+	<-w.writes
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	r.NoError(d.Shutdown(ctx))
+
+	// decoding is stopped after current cmd
+	_, err := pw.Write([]byte("*1\r\n$4\r\nPING\r\n"))
+	r.NoError(err)
+	r.NoError(<-errCh)
+}
+
+func TestDecoder_Offset_GivenCmds_EveryByteCounted(t *testing.T) {
+	r := require.New(t)
+	type testData struct {
+		data        string
+		expectedCmd Cmd
+	}
+	dp := []testData{
+		{data: "*1\r\n$4\r\nPING\r\n", expectedCmd: Cmd{"PING"}},
+		{data: "*3\r\n$3\r\nset\r\n$1\r\nk\r\n$4\r\na\r\nb\r\n", expectedCmd: Cmd{"set", "k", "a\r\nb"}},
+		{data: "*2\r\n$6\r\nselect\r\n$1\r\n1\r\n", expectedCmd: Cmd{"select", "1"}},
+		{data: "*3\r\n$3\r\nset\r\n$1\r\nk\r\n$0\r\n\r\n", expectedCmd: Cmd{"set", "k", ""}},
+	}
+
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	c := newTestConsumer()
+	d := NewDecoder(NewConn(testConn{Reader: pr, Writer: &testWriter{}}).GetReader(), c, WithOffset(100))
+	go d.Decode()
+	r.Equal(int64(100), d.Offset())
+
+	expectedOffset := int64(100)
+	for k, data := range dp {
+		t.Run(fmt.Sprintf("Data provider #%d", k), func(t *testing.T) {
+			_, err := pw.Write([]byte(data.data))
+			r.NoError(err)
+			r.Equal(data.expectedCmd, <-c.cmds)
+			expectedOffset += int64(len(data.data))
+			// offset is increased after cmd is applied by consumer
+			deadline := time.Now().Add(time.Second)
+			for d.Offset() != expectedOffset && time.Now().Before(deadline) {
+				time.Sleep(time.Millisecond)
+			}
+			r.Equal(expectedOffset, d.Offset())
+		})
+	}
+}
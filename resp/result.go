@@ -12,6 +12,7 @@ type Result struct {
 	t         byte
 	stringVal string
 	intVal    int64
+	eofMark   []byte
 }
 
 func (r *Result) String() string {
@@ -32,14 +33,30 @@ func (r *Result) IsErr() bool {
 	return r.t == ErrorOpcode
 }
 
+// GetString return value of simple string or message of error
+func (r *Result) GetString() string {
+	return r.stringVal
+}
+
 func (r *Result) IsBulkString() bool {
 	return r.t == BulkStringOpcode
 }
 
+// GetBulkStringLen return -1 for bulk string with eof mark
 func (r *Result) GetBulkStringLen() int64 {
 	return r.intVal
 }
 
+// IsEOFMarked return true if length of bulk string is unknown and it's ended by eof mark,
+// master send RDB in this way in diskless replication
+func (r *Result) IsEOFMarked() bool {
+	return r.t == BulkStringOpcode && r.eofMark != nil
+}
+
+func (r *Result) GetEOFMark() []byte {
+	return r.eofMark
+}
+
 func (r *Result) IsInt() bool {
 	return r.t == IntegerOpcode
 }